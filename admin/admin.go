@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type Controller struct {
+	r    *mux.Router
+	auth services.AdminAuth
+	e    services.RestaurantEntityInterface
+	te   services.TagEntityInterface
+	lc   services.LinkCheckerInterface
+	n    services.SubmissionNotifier
+}
+
+func NewController(router *mux.Router, auth services.AdminAuth, ei services.RestaurantEntityInterface, tei services.TagEntityInterface, lci services.LinkCheckerInterface, notifier services.SubmissionNotifier) Controller {
+	c := Controller{
+		r:    router,
+		auth: auth,
+		e:    ei,
+		te:   tei,
+		lc:   lci,
+		n:    notifier,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/admin").Subrouter()
+	s.Use(c.auth.Middleware)
+	s.HandleFunc("/tags/", c.listTags).Methods("GET")
+	s.HandleFunc("/tags/", c.createTag).Methods("POST")
+	s.HandleFunc("/tags/{slug}", c.renameTag).Methods("PUT")
+	s.HandleFunc("/tags/{slug}/merge", c.mergeTags).Methods("POST")
+	s.HandleFunc("/links/broken", c.brokenLinks).Methods("GET")
+	s.HandleFunc("/submissions", c.listSubmissions).Methods("GET")
+	s.HandleFunc("/submissions/{id}/approve", c.approveSubmission).Methods("POST")
+	s.HandleFunc("/submissions/{id}/reject", c.rejectSubmission).Methods("POST")
+	s.HandleFunc("/submissions/{id}/hide", c.hideSubmission).Methods("POST")
+
+	// merging acts on public listing URLs but is still admin only
+	c.r.Handle("/restaurants/{id}/merge", c.auth.Middleware(http.HandlerFunc(c.mergeRestaurants))).Methods("POST")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	payload, _ := json.Marshal(v)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(payload)
+}
@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func (c *Controller) brokenLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := c.lc.GetBrokenLinks()
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem loading the link report: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, links)
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type mergeRestaurantsRequest struct {
+	SourceID uint                `json:"source_id"`
+	Fields   models.MergeChoices `json:"fields"`
+}
+
+type mergeRestaurantsResponse struct {
+	Restaurant *models.Restaurant `json:"restaurant"`
+	Redirects  map[string]uint    `json:"redirects"`
+}
+
+func (c *Controller) mergeRestaurants(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	req := new(mergeRestaurantsRequest)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.SourceID == 0 {
+		http.Error(w, "source_id is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := services.ValidateMergeChoices(req.Fields); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	restaurant, redirects, err := c.e.MergeRestaurants(uint(targetID), req.SourceID, req.Fields, services.AdminFromContext(r.Context()))
+	if errors.Is(err, services.ErrMergeSameRestaurant) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem merging the restaurants: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	response := mergeRestaurantsResponse{
+		Restaurant: restaurant,
+		Redirects:  map[string]uint{},
+	}
+	for from, to := range redirects {
+		response.Redirects[strconv.Itoa(int(from))] = to
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type rejectSubmissionRequest struct {
+	Reason string `json:"reason"`
+}
+
+type moderateSubmissionResponse struct {
+	Submission models.Submission `json:"submission"`
+	Changed    bool              `json:"changed"`
+}
+
+func (c *Controller) listSubmissions(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.SubmissionPending
+	}
+
+	if !models.IsSubmissionStatus(status) {
+		http.Error(w, fmt.Sprintf("Unknown submission status: %s", status), http.StatusBadRequest)
+		return
+	}
+
+	restaurants, err := c.e.GetSubmissions(status)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem loading submissions: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	submissions := []models.Submission{}
+	for _, restaurant := range *restaurants {
+		submissions = append(submissions, models.NewSubmission(restaurant))
+	}
+
+	writeJSON(w, http.StatusOK, submissions)
+}
+
+func (c *Controller) approveSubmission(w http.ResponseWriter, r *http.Request) {
+	restID, ok := submissionID(w, r)
+	if !ok {
+		return
+	}
+
+	restaurant, approved, err := c.e.ApproveRestaurant(restID, services.AdminFromContext(r.Context()), true)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem approving the submission: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	if approved {
+		if err := c.n.SubmissionApproved(*restaurant); err != nil {
+			sentry.CaptureException(err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, moderateSubmissionResponse{Submission: models.NewSubmission(*restaurant), Changed: approved})
+}
+
+func (c *Controller) rejectSubmission(w http.ResponseWriter, r *http.Request) {
+	restID, ok := submissionID(w, r)
+	if !ok {
+		return
+	}
+
+	req := new(rejectSubmissionRequest)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !models.IsRejectionReason(req.Reason) {
+		http.Error(w, fmt.Sprintf("Unknown rejection reason: %s", req.Reason), http.StatusBadRequest)
+		return
+	}
+
+	restaurant, rejected, err := c.e.RejectRestaurant(restID, req.Reason, services.AdminFromContext(r.Context()), true)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem rejecting the submission: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	if rejected {
+		if err := c.n.SubmissionRejected(*restaurant); err != nil {
+			sentry.CaptureException(err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, moderateSubmissionResponse{Submission: models.NewSubmission(*restaurant), Changed: rejected})
+}
+
+func (c *Controller) hideSubmission(w http.ResponseWriter, r *http.Request) {
+	restID, ok := submissionID(w, r)
+	if !ok {
+		return
+	}
+
+	restaurant, hidden, err := c.e.HideRestaurant(restID, services.AdminFromContext(r.Context()))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem hiding the submission: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, moderateSubmissionResponse{Submission: models.NewSubmission(*restaurant), Changed: hidden})
+}
+
+func submissionID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	restID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return 0, false
+	}
+
+	return uint(restID), true
+}
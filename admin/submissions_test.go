@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type mockEntityInterface struct {
+	services.RestaurantEntityInterface
+	testRest *models.Restaurant
+	actors   []string
+}
+
+func (e *mockEntityInterface) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	return &[]models.Restaurant{*e.testRest}, nil
+}
+
+func (e *mockEntityInterface) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	if restaurantID != e.testRest.ID {
+		return nil, false, nil
+	}
+	if e.testRest.IsActive {
+		return e.testRest, false, nil
+	}
+
+	e.actors = append(e.actors, reviewer)
+	e.testRest.IsActive = true
+	e.testRest.ApprovedBy = &reviewer
+	return e.testRest, true, nil
+}
+
+func (e *mockEntityInterface) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	if restaurantID != e.testRest.ID {
+		return nil, false, nil
+	}
+
+	e.actors = append(e.actors, reviewer)
+	e.testRest.RejectedBy = &reviewer
+	e.testRest.RejectionReason = &reason
+	return e.testRest, true, nil
+}
+
+func (e *mockEntityInterface) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	if restaurantID != e.testRest.ID {
+		return nil, false, nil
+	}
+	if e.testRest.IsHidden {
+		return e.testRest, false, nil
+	}
+
+	e.actors = append(e.actors, actor)
+	e.testRest.IsHidden = true
+	e.testRest.HiddenBy = &actor
+	return e.testRest, true, nil
+}
+
+func buildController(e *mockEntityInterface) Controller {
+	auth := services.NewAdminAuth("linda:s3cret")
+	return NewController(mux.NewRouter(), auth, e, nil, nil, services.SubmissionNotifier{})
+}
+
+func adminRequest(c Controller, method string, target string, body string, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rr := httptest.NewRecorder()
+	c.r.ServeHTTP(rr, req)
+	return rr
+}
+
+func testRestaurant() *models.Restaurant {
+	restaurant := &models.Restaurant{Name: "Bob's Burgers"}
+	restaurant.ID = 4
+	return restaurant
+}
+
+func TestSubmissionsRequireAdmin(t *testing.T) {
+	c := buildController(&mockEntityInterface{testRest: testRestaurant()})
+
+	rr := adminRequest(c, "POST", "/admin/submissions/4/approve", "", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestListSubmissions(t *testing.T) {
+	assert := assert.New(t)
+
+	c := buildController(&mockEntityInterface{testRest: testRestaurant()})
+
+	rr := adminRequest(c, "GET", "/admin/submissions?status=pending", "", "s3cret")
+	assert.Equal(http.StatusOK, rr.Code)
+
+	var submissions []models.Submission
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &submissions))
+	assert.Len(submissions, 1)
+	assert.Equal("pending", submissions[0].Status)
+	assert.Equal("Bob's Burgers", submissions[0].Name)
+
+	rr = adminRequest(c, "GET", "/admin/submissions?status=lost", "", "s3cret")
+	assert.Equal(http.StatusBadRequest, rr.Code)
+}
+
+func TestModerateSubmissions(t *testing.T) {
+	assert := assert.New(t)
+
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildController(e)
+
+	rr := adminRequest(c, "POST", "/admin/submissions/4/approve", "", "s3cret")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(rr.Body.String(), `"status":"approved"`)
+	assert.Contains(rr.Body.String(), `"approved_by":"linda"`)
+	assert.Contains(rr.Body.String(), `"changed":true`)
+
+	rr = adminRequest(c, "POST", "/admin/submissions/4/approve", "", "s3cret")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(rr.Body.String(), `"changed":false`)
+
+	rr = adminRequest(c, "POST", "/admin/submissions/9/approve", "", "s3cret")
+	assert.Equal(http.StatusNotFound, rr.Code)
+
+	rr = adminRequest(c, "POST", "/admin/submissions/4/reject", `{"reason": "rude"}`, "s3cret")
+	assert.Equal(http.StatusBadRequest, rr.Code)
+
+	rr = adminRequest(c, "POST", "/admin/submissions/4/reject", `{"reason": "duplicate"}`, "s3cret")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(rr.Body.String(), `"rejection_reason":"duplicate"`)
+
+	rr = adminRequest(c, "POST", "/admin/submissions/4/hide", "", "s3cret")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(rr.Body.String(), `"hidden_by":"linda"`)
+	assert.Contains(rr.Body.String(), `"changed":true`)
+
+	rr = adminRequest(c, "POST", "/admin/submissions/4/hide", "", "s3cret")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(rr.Body.String(), `"changed":false`)
+
+	assert.Equal([]string{"linda", "linda", "linda"}, e.actors)
+}
@@ -0,0 +1,130 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type mergeTagsRequest struct {
+	Into string `json:"into"`
+}
+
+func (c *Controller) listTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := c.te.GetTags()
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem loading tags: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tags)
+}
+
+func (c *Controller) createTag(w http.ResponseWriter, r *http.Request) {
+	newTag, ok := decodeTag(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := c.te.CreateTag(*newTag)
+	if err == services.ErrTagExists {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving the tag: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, tag)
+}
+
+func (c *Controller) renameTag(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+
+	renamed, ok := decodeTag(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := c.te.RenameTag(slug, *renamed)
+	if err == services.ErrTagExists {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving the tag: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if tag == nil {
+		http.Error(w, "tag not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tag)
+}
+
+func (c *Controller) mergeTags(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+
+	req := new(mergeTagsRequest)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Into == "" || req.Into == slug {
+		http.Error(w, "Tags must be merged into a different tag", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := c.te.MergeTags(slug, req.Into)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem merging the tags: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if tag == nil {
+		http.Error(w, "tag not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tag)
+}
+
+func decodeTag(w http.ResponseWriter, r *http.Request) (*models.Tag, bool) {
+	tag := new(models.Tag)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&tag); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	tag.DisplayName = strings.TrimSpace(tag.DisplayName)
+	if tag.Slug == "" {
+		tag.Slug = tag.DisplayName
+	}
+	tag.Slug = models.SlugifyTag(tag.Slug)
+
+	if tag.Slug == "" || tag.DisplayName == "" {
+		http.Error(w, "Tags need a display name and a slug made of letters or digits", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return tag, true
+}
@@ -0,0 +1,48 @@
+package attributes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type Controller struct {
+	r  *mux.Router
+	ae services.AttributeEntityInterface
+}
+
+func NewController(router *mux.Router, aei services.AttributeEntityInterface) Controller {
+	c := Controller{
+		r:  router,
+		ae: aei,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/attributes").Subrouter()
+	s.HandleFunc("/", c.list).Methods("GET")
+}
+
+func (c *Controller) list(w http.ResponseWriter, r *http.Request) {
+	attributes, err := c.ae.GetAttributes()
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem loading attributes: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	payload, _ := json.Marshal(attributes)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+	return
+}
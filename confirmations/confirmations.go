@@ -0,0 +1,115 @@
+package confirmations
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+// DeviceHeader optionally identifies the app install confirming a listing, so
+// people sharing an address can each confirm it.
+const DeviceHeader = "X-Device-ID"
+
+const maxDeviceIDLength = 128
+
+// Device ids are chosen by the client, so each address is also limited to a
+// fair number of confirmations an hour.
+const (
+	ConfirmationLimit       = 30
+	ConfirmationLimitWindow = time.Hour
+)
+
+type confirmationResponse struct {
+	Counted         bool       `json:"counted"`
+	LastConfirmedAt *time.Time `json:"last_confirmed_at"`
+}
+
+type Controller struct {
+	r       *mux.Router
+	e       services.RestaurantEntityInterface
+	cfe     services.ConfirmationEntityInterface
+	limiter *services.RateLimiter
+	hasher  services.ClientHasher
+}
+
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, cfei services.ConfirmationEntityInterface, limiter *services.RateLimiter, hasher services.ClientHasher) Controller {
+	c := Controller{
+		r:       router,
+		e:       ei,
+		cfe:     cfei,
+		limiter: limiter,
+		hasher:  hasher,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/restaurants").Subrouter()
+	s.HandleFunc("/{id}/confirmations", c.create).Methods("POST")
+}
+
+// create records that a listing was seen open. Repeat confirmations from the
+// same device or address are accepted but not counted again.
+func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	restID, err := strconv.ParseUint(strings.TrimSpace(vars["id"]), 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	clientIP := services.ClientIP(r)
+	if !c.limiter.Allow(clientIP) {
+		http.Error(w, "Too many confirmations, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your confirmation: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil || !restaurant.IsPublic() || restaurant.IsHidden {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	client := "ip:" + clientIP
+	if deviceID := strings.TrimSpace(r.Header.Get(DeviceHeader)); deviceID != "" && len(deviceID) <= maxDeviceIDLength {
+		client = "device:" + deviceID
+	}
+
+	counted, lastConfirmedAt, err := c.cfe.ConfirmOpen(restaurant.ID, c.hasher.Hash(client))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your confirmation: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	if counted {
+		status = http.StatusCreated
+	}
+
+	payload, _ := json.Marshal(confirmationResponse{
+		Counted:         counted,
+		LastConfirmedAt: lastConfirmedAt,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(payload)
+	return
+}
@@ -0,0 +1,348 @@
+package confirmations
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type handlerTests struct {
+	description        string
+	url                string
+	method             string
+	remoteAddr         string
+	deviceID           string
+	expectedStatusCode int
+	expectedBody       string
+	entityClient       services.RestaurantEntityInterface
+	confirmationClient services.ConfirmationEntityInterface
+	limiter            *services.RateLimiter
+}
+
+var testHasher = services.ClientHasher{Secret: []byte("test-secret")}
+
+func buildController(ei services.RestaurantEntityInterface, cfei services.ConfirmationEntityInterface, limiter *services.RateLimiter) Controller {
+	if limiter == nil {
+		limiter = services.NewRateLimiter(ConfirmationLimit, ConfirmationLimitWindow)
+	}
+
+	controller := NewController(mux.NewRouter(), ei, cfei, limiter, testHasher)
+	return controller
+}
+
+func runTestCases(t *testing.T, testcases []handlerTests) {
+	assert := assert.New(t)
+
+	for _, testcase := range testcases {
+		c := buildController(testcase.entityClient, testcase.confirmationClient, testcase.limiter)
+
+		req, err := http.NewRequest(testcase.method, testcase.url, nil)
+		assert.NoError(err)
+		req.RemoteAddr = testcase.remoteAddr
+		if testcase.deviceID != "" {
+			req.Header.Set(DeviceHeader, testcase.deviceID)
+		}
+
+		rr := httptest.NewRecorder()
+		c.r.ServeHTTP(rr, req)
+
+		assert.Equal(testcase.expectedStatusCode, rr.Code, testcase.description)
+		assert.Equal(testcase.expectedBody, rr.Body.String(), testcase.description)
+	}
+}
+
+type mockTestMode int
+
+const (
+	Success mockTestMode = iota
+	Fail
+	Error
+	NotFound
+)
+
+type mockEntityInterface struct {
+	mode     mockTestMode
+	testRest *models.Restaurant
+}
+
+func (e mockEntityInterface) CreateRestaurant(newRestaurant models.Restaurant) (*uint, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetRestaurants(lat *float64, lng *float64, filters models.RestaurantFilters) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetRestaurant(id uint) (*models.Restaurant, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, nil
+	case Fail:
+		return nil, errors.New("could not find restaurant by id")
+	case NotFound:
+		return nil, nil
+	}
+
+	return nil, nil
+}
+
+func (e mockEntityInterface) MergeRestaurants(targetID uint, sourceID uint, choices models.MergeChoices, actor string) (*models.Restaurant, map[uint]uint, error) {
+	return nil, nil, nil
+}
+
+func (e mockEntityInterface) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) SearchRestaurants(term string, limit int) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetModerationStats() (*models.ModerationStats, error) {
+	return nil, nil
+}
+
+var confirmedAt = time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+
+// mockConfirmationEntity dedupes clients forever, which is enough to tell
+// which client key the controller used. Cases sharing a clients map see each
+// other's confirmations.
+type mockConfirmationEntity struct {
+	mode    mockTestMode
+	clients map[string]bool
+}
+
+func (e mockConfirmationEntity) ConfirmOpen(restaurantID uint, clientHash string) (bool, *time.Time, error) {
+	switch e.mode {
+	case Success:
+		if e.clients[clientHash] {
+			return false, &confirmedAt, nil
+		}
+
+		e.clients[clientHash] = true
+		return true, &confirmedAt, nil
+	case Fail:
+		return false, nil, errors.New("could not write confirmation to db")
+	}
+
+	return false, nil, nil
+}
+
+func testRestaurant() *models.Restaurant {
+	restaurant := &models.Restaurant{Name: "Bob's Burgers", IsActive: true}
+	restaurant.ID = 4
+
+	return restaurant
+}
+
+const (
+	countedBody    = `{"counted":true,"last_confirmed_at":"2020-04-02T15:30:00Z"}`
+	notCountedBody = `{"counted":false,"last_confirmed_at":"2020-04-02T15:30:00Z"}`
+)
+
+func TestCreateHandler(t *testing.T) {
+	hidden := testRestaurant()
+	hidden.IsHidden = true
+
+	rejectedAt := time.Now()
+	rejected := testRestaurant()
+	rejected.RejectedAt = &rejectedAt
+
+	tests := []handlerTests{
+		{
+			description:        "successful confirmation",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       countedBody,
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			confirmationClient: mockConfirmationEntity{mode: Success, clients: map[string]bool{}},
+		}, {
+			description:        "invalid restaurant id",
+			url:                "/restaurants/abc/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			confirmationClient: mockConfirmationEntity{mode: Success, clients: map[string]bool{}},
+		}, {
+			description:        "unknown restaurant",
+			url:                "/restaurants/9/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: NotFound},
+			confirmationClient: mockConfirmationEntity{mode: Success, clients: map[string]bool{}},
+		}, {
+			description:        "hidden restaurant",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: hidden},
+			confirmationClient: mockConfirmationEntity{mode: Success, clients: map[string]bool{}},
+		}, {
+			description:        "rejected restaurant",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: rejected},
+			confirmationClient: mockConfirmationEntity{mode: Success, clients: map[string]bool{}},
+		}, {
+			description:        "failed restaurant lookup",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your confirmation: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Fail},
+			confirmationClient: mockConfirmationEntity{mode: Success, clients: map[string]bool{}},
+		}, {
+			description:        "failed confirmation",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your confirmation: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			confirmationClient: mockConfirmationEntity{mode: Fail},
+		},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCreateHandlerDedupesByDeviceOrAddress(t *testing.T) {
+	entity := mockEntityInterface{mode: Success, testRest: testRestaurant()}
+	confirmations := mockConfirmationEntity{mode: Success, clients: map[string]bool{}}
+
+	tests := []handlerTests{
+		{
+			description:        "first confirmation from an address",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       countedBody,
+			entityClient:       entity,
+			confirmationClient: confirmations,
+		}, {
+			description:        "same address on another port",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:6000",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       notCountedBody,
+			entityClient:       entity,
+			confirmationClient: confirmations,
+		}, {
+			description:        "device behind that address",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:6000",
+			deviceID:           "phone-1",
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       countedBody,
+			entityClient:       entity,
+			confirmationClient: confirmations,
+		}, {
+			description:        "same device from another address",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "198.51.100.7:6000",
+			deviceID:           "phone-1",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       notCountedBody,
+			entityClient:       entity,
+			confirmationClient: confirmations,
+		},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCreateHandlerRateLimit(t *testing.T) {
+	entity := mockEntityInterface{mode: Success, testRest: testRestaurant()}
+	confirmations := mockConfirmationEntity{mode: Success, clients: map[string]bool{}}
+	limiter := services.NewRateLimiter(2, time.Hour)
+
+	// a fresh device id on every request doesn't get around the limit
+	tests := []handlerTests{
+		{
+			description:        "first device",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			deviceID:           "phone-1",
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       countedBody,
+			entityClient:       entity,
+			confirmationClient: confirmations,
+			limiter:            limiter,
+		}, {
+			description:        "second device",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			deviceID:           "phone-2",
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       countedBody,
+			entityClient:       entity,
+			confirmationClient: confirmations,
+			limiter:            limiter,
+		}, {
+			description:        "third device over the limit",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			deviceID:           "phone-3",
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedBody:       "Too many confirmations, please try again later\n",
+			entityClient:       entity,
+			confirmationClient: confirmations,
+			limiter:            limiter,
+		}, {
+			description:        "another address",
+			url:                "/restaurants/4/confirmations",
+			method:             "POST",
+			remoteAddr:         "198.51.100.7:5123",
+			deviceID:           "phone-3",
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       countedBody,
+			entityClient:       entity,
+			confirmationClient: confirmations,
+			limiter:            limiter,
+		},
+	}
+
+	runTestCases(t, tests)
+}
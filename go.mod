@@ -7,13 +7,14 @@ require (
 	github.com/go-playground/validator v9.31.0+incompatible
 	github.com/go-playground/validator/v10 v10.2.0 // indirect
 	github.com/golang-migrate/migrate v3.5.4+incompatible // indirect
-	github.com/golang-migrate/migrate/v4 v4.10.0 // indirect
-	github.com/gorilla/handlers v1.4.2 // indirect
+	github.com/golang-migrate/migrate/v4 v4.10.0
+	github.com/gorilla/handlers v1.4.2
 	github.com/gorilla/mux v1.7.4
 	github.com/jmoiron/sqlx v1.2.0
-	github.com/joho/godotenv v1.3.0 // indirect
+	github.com/joho/godotenv v1.3.0
 	github.com/lib/pq v1.3.0
 	github.com/paulmach/orb v0.1.5
-	github.com/rs/cors v1.7.0 // indirect
+	github.com/rs/cors v1.7.0
 	github.com/stretchr/testify v1.4.0
+	golang.org/x/image v0.0.0-20200430140353-33d19683fad8
 )
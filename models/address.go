@@ -0,0 +1,143 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+const DefaultCountry = "US"
+
+// countryCodes holds the officially assigned ISO 3166-1 alpha-2 codes.
+var countryCodes = toSet(strings.Fields(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ
+	BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR
+	CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR
+	GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU
+	ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ
+	LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ
+	MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF
+	PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI
+	SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR
+	TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW
+`))
+
+type addressRules struct {
+	PostalCode     *regexp.Regexp
+	RegionRequired bool
+	Regions        map[string]bool
+}
+
+var countryAddressRules = map[string]addressRules{
+	"US": {
+		PostalCode:     regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+		RegionRequired: true,
+		Regions: toSet(strings.Fields(`
+			AL AK AZ AR CA CO CT DE DC FL GA HI ID IL IN IA KS KY LA ME MD MA MI MN
+			MS MO MT NE NV NH NJ NM NY NC ND OH OK OR PA RI SC SD TN TX UT VT VA WA
+			WV WI WY AS GU MP PR VI
+		`)),
+	},
+	// Canadian listings may give a full postal code or just the forward
+	// sortation area (the first three characters).
+	"CA": {
+		PostalCode:     regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z]( \d[ABCEGHJ-NPRSTV-Z]\d)?$`),
+		RegionRequired: true,
+		Regions:        toSet(strings.Fields(`AB BC MB NB NL NS NT NU ON PE QC SK YT`)),
+	},
+	"GB": {
+		PostalCode: regexp.MustCompile(`^([A-Z]{1,2}\d[A-Z\d]? \d[A-Z]{2}|GIR 0AA)$`),
+	},
+}
+
+// IsCountryCode reports whether code is an ISO 3166-1 alpha-2 country code.
+func IsCountryCode(code string) bool {
+	return countryCodes[code]
+}
+
+// NormalizeCountry uppercases a country code, defaulting to the US.
+func NormalizeCountry(country string) string {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country == "" {
+		return DefaultCountry
+	}
+
+	return country
+}
+
+// NormalizePostalCode uppercases a postal code and, for Canada and the UK,
+// puts the single space between the outward and inward parts.
+func NormalizePostalCode(country string, postalCode string) string {
+	code := strings.ToUpper(strings.Join(strings.Fields(postalCode), ""))
+
+	switch country {
+	case "CA":
+		if len(code) == 6 {
+			code = code[:3] + " " + code[3:]
+		}
+	case "GB":
+		if len(code) >= 5 {
+			code = code[:len(code)-3] + " " + code[len(code)-3:]
+		}
+	case "US":
+		if len(code) == 9 && !strings.Contains(code, "-") {
+			code = code[:5] + "-" + code[5:]
+		}
+	}
+
+	return code
+}
+
+// NormalizeRegion uppercases region codes for countries that use them.
+func NormalizeRegion(country string, region string) string {
+	region = strings.TrimSpace(region)
+	if rules, ok := countryAddressRules[country]; ok && rules.Regions != nil {
+		return strings.ToUpper(region)
+	}
+
+	return region
+}
+
+// InvalidAddressFields returns the names of the Restaurant fields that do not
+// match the postal code and region rules for the given country.
+func InvalidAddressFields(country string, region string, postalCode string) []string {
+	if !IsCountryCode(country) {
+		return []string{"Country"}
+	}
+
+	invalid := []string{}
+
+	rules, ok := countryAddressRules[country]
+	if !ok {
+		return invalid
+	}
+
+	if rules.RegionRequired && region == "" {
+		invalid = append(invalid, "State")
+	} else if region != "" && rules.Regions != nil && !rules.Regions[region] {
+		invalid = append(invalid, "State")
+	}
+
+	if rules.PostalCode != nil && !rules.PostalCode.MatchString(postalCode) {
+		invalid = append(invalid, "Zipcode")
+	}
+
+	return invalid
+}
+
+// FSA returns the forward sortation area of a Canadian postal code.
+func FSA(postalCode string) string {
+	if len(postalCode) < 3 {
+		return postalCode
+	}
+
+	return postalCode[:3]
+}
+
+func toSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidAddressFields(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		country  string
+		region   string
+		postal   string
+		expected []string
+	}{
+		{"US", "SD", "57106", []string{}},
+		{"US", "SD", "57106-1234", []string{}},
+		{"US", "", "5710", []string{"State", "Zipcode"}},
+		{"US", "XX", "57106", []string{"State"}},
+		{"CA", "ON", "K1A 0A9", []string{}},
+		{"CA", "QC", "H2X", []string{}},
+		{"CA", "ON", "D1A 0A9", []string{"Zipcode"}},
+		{"GB", "", "SW1A 1AA", []string{}},
+		{"GB", "", "EC1A 1BB", []string{}},
+		{"GB", "", "90210", []string{"Zipcode"}},
+		{"DE", "", "10115", []string{}},
+		{"ZZ", "", "", []string{"Country"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.expected, InvalidAddressFields(test.country, test.region, test.postal), test)
+	}
+}
+
+func TestNormalizePostalCode(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("K1A 0A9", NormalizePostalCode("CA", "k1a0a9"))
+	assert.Equal("H2X", NormalizePostalCode("CA", " h2x "))
+	assert.Equal("SW1A 1AA", NormalizePostalCode("GB", "sw1a1aa"))
+	assert.Equal("57106-1234", NormalizePostalCode("US", "571061234"))
+}
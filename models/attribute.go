@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Attribute categories.
+const (
+	AttributeDietary       = "dietary"
+	AttributeAccessibility = "accessibility"
+)
+
+// Attribute is a structured fact a listing can have, such as "vegan" or
+// "wheelchair_accessible_pickup". Listings refer to attributes by slug.
+type Attribute struct {
+	CommonModelFields
+	Slug      string    `db:"slug" json:"slug"`
+	Name      string    `db:"name" json:"name"`
+	Category  string    `db:"category" json:"category"`
+	CreatedAt time.Time `db:"created_at" json:"-"`
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Edit sources describe who proposed a change to a listing.
+const (
+	EditSourceOwner      = "owner"
+	EditSourceSuggestion = "suggestion"
+)
+
+// Edit statuses.
+const (
+	EditPending  = "pending"
+	EditApproved = "approved"
+	EditRejected = "rejected"
+)
+
+// RestaurantUpdate is a partial change to a listing. Only the fields that are
+// set are changed.
+type RestaurantUpdate struct {
+	Name             *string           `json:"name,omitempty" validate:"omitempty,min=1"`
+	Type             *string           `json:"type,omitempty" validate:"omitempty,min=1"`
+	Tags             *RestaurantTags   `json:"tags,omitempty"`
+	Phone            *string           `json:"phone,omitempty"`
+	Details          *string           `json:"details,omitempty"`
+	Hours            *string           `json:"hours,omitempty"`
+	Email            *string           `json:"email,omitempty"`
+	URL              *string           `json:"url,omitempty"`
+	OrderingChannels *OrderingChannels `json:"ordering_channels,omitempty"`
+	Address          *string           `json:"address,omitempty"`
+	Address2         *string           `json:"address_2,omitempty"`
+	City             *string           `json:"city,omitempty"`
+	State            *string           `json:"state,omitempty"`
+	Zipcode          *string           `json:"zipcode,omitempty"`
+	Country          *string           `json:"country,omitempty"`
+	DonateURL        *string           `json:"donate_url,omitempty"`
+	HasGiftCard      *bool             `json:"giftcard,omitempty"`
+	PaymentMethods   *PaymentMethods   `json:"payment_methods,omitempty"`
+	Translations     *Translations     `json:"translations,omitempty"`
+	ServiceModes     *ServiceModes     `json:"service_modes,omitempty"`
+	LatLng           *GeoPoint         `json:"latlng,omitempty"`
+}
+
+// ChangesAddress reports whether the update touches any part of the address.
+func (u RestaurantUpdate) ChangesAddress() bool {
+	return u.Address != nil || u.Address2 != nil || u.City != nil || u.State != nil || u.Zipcode != nil || u.Country != nil
+}
+
+// IsEmpty reports whether the update changes nothing.
+func (u RestaurantUpdate) IsEmpty() bool {
+	return u == RestaurantUpdate{}
+}
+
+// Split separates the update into fields that can be applied straight away
+// and high-risk fields that need moderation: the name, the address, and the
+// contact email and website, whose domains ownership claims are checked
+// against.
+func (u RestaurantUpdate) Split() (lowRisk RestaurantUpdate, highRisk RestaurantUpdate) {
+	highRisk = RestaurantUpdate{
+		Name:     u.Name,
+		Email:    u.Email,
+		URL:      u.URL,
+		Address:  u.Address,
+		Address2: u.Address2,
+		City:     u.City,
+		State:    u.State,
+		Zipcode:  u.Zipcode,
+		Country:  u.Country,
+		LatLng:   u.LatLng,
+	}
+
+	lowRisk = u
+	lowRisk.Name = nil
+	lowRisk.Email = nil
+	lowRisk.URL = nil
+	lowRisk.Address = nil
+	lowRisk.Address2 = nil
+	lowRisk.City = nil
+	lowRisk.State = nil
+	lowRisk.Zipcode = nil
+	lowRisk.Country = nil
+	lowRisk.LatLng = nil
+
+	return lowRisk, highRisk
+}
+
+// Apply copies the set fields onto a listing.
+func (u RestaurantUpdate) Apply(r *Restaurant) {
+	setString := func(target *string, value *string) {
+		if value != nil {
+			*target = *value
+		}
+	}
+
+	setString(&r.Name, u.Name)
+	setString(&r.Type, u.Type)
+	setString(&r.Phone, u.Phone)
+	setString(&r.Details, u.Details)
+	setString(&r.Hours, u.Hours)
+	setString(&r.Email, u.Email)
+	setString(&r.URL, u.URL)
+	setString(&r.Address, u.Address)
+	setString(&r.Address2, u.Address2)
+	setString(&r.City, u.City)
+	setString(&r.State, u.State)
+	setString(&r.Zipcode, u.Zipcode)
+	setString(&r.Country, u.Country)
+	setString(&r.DonateURL, u.DonateURL)
+
+	if u.Tags != nil {
+		r.Tags = *u.Tags
+	}
+	if u.OrderingChannels != nil {
+		r.OrderingChannels = *u.OrderingChannels
+	}
+	if u.HasGiftCard != nil {
+		r.HasGiftCard = *u.HasGiftCard
+	}
+	if u.PaymentMethods != nil {
+		r.PaymentMethods = *u.PaymentMethods
+	}
+	if u.Translations != nil {
+		r.Translations = *u.Translations
+	}
+	if u.ServiceModes != nil {
+		r.ServiceModes = *u.ServiceModes
+	}
+	if u.LatLng != nil {
+		r.LatLng = *u.LatLng
+	}
+}
+
+func (u RestaurantUpdate) Value() (driver.Value, error) {
+	encoded, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+func (u *RestaurantUpdate) Scan(src interface{}) error {
+	var source []byte
+	switch src.(type) {
+	case string:
+		source = []byte(src.(string))
+	case []byte:
+		source = src.([]byte)
+	case nil:
+		*u = RestaurantUpdate{}
+		return nil
+	default:
+		return errors.New("Incompatible type for RestaurantUpdate")
+	}
+
+	return json.Unmarshal(source, u)
+}
+
+// BusinessEdit is a proposed change to a listing that waits for a moderator.
+type BusinessEdit struct {
+	CommonModelFields
+	BusinessID uint             `db:"business_id" json:"restaurant_id"`
+	Source     string           `db:"source" json:"source"`
+	Actor      string           `db:"actor" json:"-"`
+	Changes    RestaurantUpdate `db:"changes" json:"changes"`
+	Status     string           `db:"status" json:"status"`
+	ReviewedBy *string          `db:"reviewed_by" json:"-"`
+	ReviewedAt *time.Time       `db:"reviewed_at" json:"reviewed_at"`
+	CreatedAt  time.Time        `db:"created_at" json:"created_at"`
+}
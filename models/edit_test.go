@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestaurantUpdateSplitAndApply(t *testing.T) {
+	assert := assert.New(t)
+
+	name := "New Name"
+	hours := "11-9"
+	city := "Fargo"
+	giftcard := false
+	website := "https://bobsburgers.example"
+	update := RestaurantUpdate{
+		Name:        &name,
+		URL:         &website,
+		Hours:       &hours,
+		City:        &city,
+		HasGiftCard: &giftcard,
+	}
+
+	lowRisk, highRisk := update.Split()
+	assert.Equal(RestaurantUpdate{Hours: &hours, HasGiftCard: &giftcard}, lowRisk)
+	assert.Equal(RestaurantUpdate{Name: &name, URL: &website, City: &city}, highRisk)
+	assert.True(highRisk.ChangesAddress())
+	assert.False(lowRisk.ChangesAddress())
+	assert.True(RestaurantUpdate{}.IsEmpty())
+
+	restaurant := Restaurant{Name: "Old Name", Hours: "9-5", City: "Sioux Falls", Details: "kept", HasGiftCard: true}
+	lowRisk.Apply(&restaurant)
+	assert.Equal("Old Name", restaurant.Name)
+	assert.Equal("11-9", restaurant.Hours)
+	assert.Equal("kept", restaurant.Details)
+	assert.False(restaurant.HasGiftCard)
+
+	value, err := highRisk.Value()
+	assert.NoError(err)
+
+	var scanned RestaurantUpdate
+	assert.NoError(scanned.Scan([]byte(value.(string))))
+	assert.Equal(highRisk, scanned)
+}
@@ -0,0 +1,9 @@
+package models
+
+type Email struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string
+}
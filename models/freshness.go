@@ -0,0 +1,51 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Each freshness signal loses half its weight after its half-life.
+const (
+	ownerUpdateHalfLife  = 14 * 24 * time.Hour
+	confirmationHalfLife = 7 * 24 * time.Hour
+	updatedAtHalfLife    = 30 * 24 * time.Hour
+)
+
+// How much a brand new signal counts towards the score. An update from the
+// owner is the strongest sign that a listing is accurate.
+const (
+	ownerUpdateWeight  = 1.0
+	confirmationWeight = 0.8
+	updatedAtWeight    = 0.5
+)
+
+// ComputeFreshness scores how likely a listing is to be current, from 0 for no
+// recent activity to 1. Signals combine like independent probabilities, so a
+// recent confirmation and a recent owner update together score higher than
+// either alone.
+func (r Restaurant) ComputeFreshness(now time.Time) float64 {
+	stale := 1.0
+
+	if r.OwnerUpdatedAt != nil {
+		stale *= 1 - ownerUpdateWeight*decay(now.Sub(*r.OwnerUpdatedAt), ownerUpdateHalfLife)
+	}
+
+	if r.LastConfirmedAt != nil {
+		stale *= 1 - confirmationWeight*decay(now.Sub(*r.LastConfirmedAt), confirmationHalfLife)
+	}
+
+	if !r.UpdatedAt.IsZero() {
+		stale *= 1 - updatedAtWeight*decay(now.Sub(r.UpdatedAt), updatedAtHalfLife)
+	}
+
+	return math.Round((1-stale)*1000) / 1000
+}
+
+func decay(age time.Duration, halfLife time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+
+	return math.Pow(0.5, float64(age)/float64(halfLife))
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeFreshness(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(days int) *time.Time {
+		at := now.Add(-time.Duration(days) * 24 * time.Hour)
+		return &at
+	}
+
+	var untouched Restaurant
+	assert.Equal(0.0, untouched.ComputeFreshness(now))
+
+	var updated Restaurant
+	updated.UpdatedAt = *ago(0)
+	assert.Equal(0.5, updated.ComputeFreshness(now))
+
+	updated.UpdatedAt = *ago(30)
+	assert.Equal(0.25, updated.ComputeFreshness(now))
+
+	confirmed := updated
+	confirmed.LastConfirmedAt = ago(7)
+	assert.Equal(0.55, confirmed.ComputeFreshness(now))
+
+	// a recent owner update outweighs everything else
+	owned := confirmed
+	owned.OwnerUpdatedAt = ago(0)
+	assert.Equal(1.0, owned.ComputeFreshness(now))
+
+	assert.True(confirmed.ComputeFreshness(now) > updated.ComputeFreshness(now))
+
+	// old signals fade towards zero
+	stale := Restaurant{LastConfirmedAt: ago(90)}
+	stale.UpdatedAt = *ago(365)
+	assert.True(stale.ComputeFreshness(now) < 0.01)
+}
@@ -10,6 +10,7 @@ type GeocodioResult struct {
 
 type Zipcode struct {
 	ID      uint    `db:"id"`
+	Country string  `db:"country"`
 	Zipcode string  `db:"zipcode"`
 	Lat     float64 `db:"lat"`
 	Lng     float64 `db:"lng"`
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type LinkCheck struct {
+	CommonModelFields
+	BusinessID   uint      `db:"business_id" json:"restaurant_id"`
+	BusinessName string    `db:"business_name" json:"restaurant_name,omitempty"`
+	Field        string    `db:"field" json:"field"`
+	URL          string    `db:"url" json:"url"`
+	StatusCode   int       `db:"status_code" json:"status_code"`
+	RedirectURL  string    `db:"redirect_url" json:"redirect_url"`
+	Error        string    `db:"error" json:"error"`
+	IsBroken     bool      `db:"is_broken" json:"broken"`
+	CheckedAt    time.Time `db:"checked_at" json:"checked_at"`
+}
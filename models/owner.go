@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type Owner struct {
+	CommonModelFields
+	BusinessID uint      `db:"business_id" json:"restaurant_id"`
+	Email      string    `db:"email" json:"email"`
+	VerifiedAt time.Time `db:"verified_at" json:"verified_at"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
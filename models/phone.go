@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrInvalidPhone = errors.New("phone number is not valid")
+
+	phoneExtension    = regexp.MustCompile(`(?i)\s*(?:,|;|#|x|ext\.?|extension)\s*(\d{1,6})\s*$`)
+	phoneAllowedChars = regexp.MustCompile(`^[\d\s().\-+/]+$`)
+	nonDigits         = regexp.MustCompile(`\D`)
+)
+
+// callingCodes maps countries to their international calling code. Countries
+// missing here need numbers submitted with a leading +.
+var callingCodes = map[string]string{
+	"US": "1", "CA": "1", "PR": "1", "GU": "1", "VI": "1", "AS": "1", "MP": "1",
+	"GB": "44", "IE": "353", "FR": "33", "DE": "49", "ES": "34", "IT": "39",
+	"NL": "31", "BE": "32", "CH": "41", "AT": "43", "PT": "351", "SE": "46",
+	"NO": "47", "DK": "45", "FI": "358", "PL": "48", "MX": "52", "BR": "55",
+	"AR": "54", "AU": "61", "NZ": "64", "JP": "81", "KR": "82", "CN": "86",
+	"IN": "91", "VN": "84", "PH": "63", "SG": "65", "ZA": "27",
+}
+
+type PhoneNumber struct {
+	E164      string
+	Extension string
+}
+
+// ParsePhone normalizes a phone number to E.164. Numbers without a leading +
+// (or international dialing prefix) are read as national numbers in country.
+func ParsePhone(raw string, country string) (*PhoneNumber, error) {
+	phone := &PhoneNumber{}
+
+	number := strings.TrimSpace(raw)
+	if match := phoneExtension.FindStringSubmatchIndex(number); match != nil {
+		phone.Extension = number[match[2]:match[3]]
+		number = number[:match[0]]
+	}
+
+	if number == "" || !phoneAllowedChars.MatchString(number) || strings.LastIndex(number, "+") > 0 {
+		return nil, ErrInvalidPhone
+	}
+
+	digits := nonDigits.ReplaceAllString(number, "")
+
+	international := strings.HasPrefix(number, "+")
+	if !international && (country == "US" || country == "CA") && strings.HasPrefix(digits, "011") {
+		international = true
+		digits = digits[3:]
+	} else if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	if international {
+		if strings.HasPrefix(digits, "1") {
+			if !isNANPNumber(digits[1:]) {
+				return nil, ErrInvalidPhone
+			}
+		} else if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+			return nil, ErrInvalidPhone
+		}
+
+		phone.E164 = "+" + digits
+		return phone, nil
+	}
+
+	callingCode, ok := callingCodes[country]
+	if !ok {
+		return nil, ErrInvalidPhone
+	}
+
+	if callingCode == "1" {
+		if len(digits) == 11 && digits[0] == '1' {
+			digits = digits[1:]
+		}
+
+		if !isNANPNumber(digits) {
+			return nil, ErrInvalidPhone
+		}
+	} else {
+		// drop the trunk prefix used when dialing within the country
+		digits = strings.TrimPrefix(digits, "0")
+		if len(digits) < 6 || len(callingCode)+len(digits) > 15 {
+			return nil, ErrInvalidPhone
+		}
+	}
+
+	phone.E164 = "+" + callingCode + digits
+	return phone, nil
+}
+
+// isNANPNumber checks a ten digit North American number, where neither the
+// area code nor the exchange may start with 0 or 1.
+func isNANPNumber(digits string) bool {
+	return len(digits) == 10 &&
+		digits[0] >= '2' && digits[3] >= '2' &&
+		nonDigits.FindString(digits) == ""
+}
+
+// FormatPhone formats an E.164 number the way it is written nationally,
+// falling back to a spaced international format for other countries.
+func FormatPhone(e164 string, extension string) string {
+	if e164 == "" {
+		return ""
+	}
+
+	var formatted string
+	digits := strings.TrimPrefix(e164, "+")
+
+	switch {
+	case strings.HasPrefix(digits, "1") && len(digits) == 11:
+		formatted = fmt.Sprintf("(%s) %s-%s", digits[1:4], digits[4:7], digits[7:])
+	case strings.HasPrefix(digits, "44") && len(digits) == 12:
+		national := "0" + digits[2:]
+		if strings.HasPrefix(national, "02") {
+			formatted = fmt.Sprintf("%s %s %s", national[:3], national[3:7], national[7:])
+		} else {
+			formatted = fmt.Sprintf("%s %s", national[:5], national[5:])
+		}
+	default:
+		formatted = e164
+	}
+
+	if extension != "" {
+		formatted = fmt.Sprintf("%s ext. %s", formatted, extension)
+	}
+
+	return formatted
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePhone(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		raw       string
+		country   string
+		e164      string
+		extension string
+		display   string
+	}{
+		{"605-555-0100", "US", "+16055550100", "", "(605) 555-0100"},
+		{"(605) 555.0100 ext. 12", "US", "+16055550100", "12", "(605) 555-0100 ext. 12"},
+		{"1 605 555 0100 x7", "US", "+16055550100", "7", "(605) 555-0100 ext. 7"},
+		{"+1 613 555 0199", "CA", "+16135550199", "", "(613) 555-0199"},
+		{"020 7946 0000", "GB", "+442079460000", "", "020 7946 0000"},
+		{"07700 900123", "GB", "+447700900123", "", "07700 900123"},
+		{"+49 30 1234567", "US", "+49301234567", "", "+49301234567"},
+		{"011 44 20 7946 0000", "US", "+442079460000", "", "020 7946 0000"},
+	}
+
+	for _, test := range tests {
+		phone, err := ParsePhone(test.raw, test.country)
+		if assert.NoError(err, test.raw) {
+			assert.Equal(test.e164, phone.E164, test.raw)
+			assert.Equal(test.extension, phone.Extension, test.raw)
+			assert.Equal(test.display, FormatPhone(phone.E164, phone.Extension), test.raw)
+		}
+	}
+}
+
+func TestParsePhoneInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, raw := range []string{"", "call us!", "555-0100", "123-456-7890", "+1 605 555", "605+555+0100"} {
+		_, err := ParsePhone(raw, "US")
+		assert.Equal(ErrInvalidPhone, err, raw)
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Photo struct {
+	CommonModelFields
+	BusinessID   uint       `db:"business_id" json:"restaurant_id"`
+	StorageKey   string     `db:"storage_key" json:"-"`
+	ThumbnailKey string     `db:"thumbnail_key" json:"-"`
+	URL          string     `db:"url" json:"url"`
+	ThumbnailURL string     `db:"thumbnail_url" json:"thumbnail_url"`
+	ContentType  string     `db:"content_type" json:"content_type"`
+	Width        int        `db:"width" json:"width"`
+	Height       int        `db:"height" json:"height"`
+	IsApproved   bool       `db:"is_approved" json:"approved"`
+	ApprovedAt   *time.Time `db:"approved_at" json:"-"`
+	ApprovedBy   *string    `db:"approved_by" json:"-"`
+	CommonModelTimestamps
+}
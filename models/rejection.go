@@ -0,0 +1,34 @@
+package models
+
+// Reasons a moderator can give for rejecting a submission.
+const (
+	RejectionSpam       = "spam"
+	RejectionDuplicate  = "duplicate"
+	RejectionOutOfScope = "out_of_scope"
+	RejectionIncomplete = "incomplete"
+)
+
+// RejectionReasons lists the reasons in the order moderators pick from.
+var RejectionReasons = []string{RejectionSpam, RejectionDuplicate, RejectionOutOfScope, RejectionIncomplete}
+
+var rejectionLabels = map[string]string{
+	RejectionSpam:       "Spam",
+	RejectionDuplicate:  "Duplicate listing",
+	RejectionOutOfScope: "Out of scope",
+	RejectionIncomplete: "Incomplete submission",
+}
+
+// IsRejectionReason reports whether reason is a known rejection reason.
+func IsRejectionReason(reason string) bool {
+	_, ok := rejectionLabels[reason]
+	return ok
+}
+
+// RejectionLabel describes a rejection reason for people.
+func RejectionLabel(reason string) string {
+	if label, ok := rejectionLabels[reason]; ok {
+		return label
+	}
+
+	return reason
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Report reasons.
+const (
+	ReportClosed       = "closed"
+	ReportWrongHours   = "wrong_hours"
+	ReportWrongAddress = "wrong_address"
+	ReportSpam         = "spam"
+)
+
+// Report statuses. A report is actioned when a moderator hides the listing
+// because of it.
+const (
+	ReportPending   = "pending"
+	ReportDismissed = "dismissed"
+	ReportActioned  = "actioned"
+)
+
+// HiddenByReports marks listings that were hidden automatically after enough
+// people reported them, rather than by a moderator.
+const HiddenByReports = "reports"
+
+type Report struct {
+	CommonModelFields
+	BusinessID   uint       `db:"business_id" json:"restaurant_id"`
+	Reason       string     `db:"reason" json:"reason" validate:"required,oneof=closed wrong_hours wrong_address spam"`
+	Note         string     `db:"note" json:"note" validate:"max=500"`
+	ReporterHash string     `db:"reporter_hash" json:"-"`
+	Status       string     `db:"status" json:"status"`
+	ReviewedBy   *string    `db:"reviewed_by" json:"-"`
+	ReviewedAt   *time.Time `db:"reviewed_at" json:"-"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
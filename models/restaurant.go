@@ -2,10 +2,14 @@ package models
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type CommonModelFields struct {
@@ -21,59 +25,314 @@ type CommonModelTimestamps struct {
 type RestaurantTags []string
 
 func (t RestaurantTags) Value() (driver.Value, error) {
-	return fmt.Sprintf("{%s}", strings.Join(t, ",")), nil
+	if t == nil {
+		return "{}", nil
+	}
+
+	return pq.StringArray(t).Value()
 }
 
 func (t *RestaurantTags) Scan(src interface{}) error {
+	var tags pq.StringArray
+	if err := tags.Scan(src); err != nil {
+		return err
+	}
+
+	if tags == nil {
+		tags = pq.StringArray{}
+	}
+
+	*t = RestaurantTags(tags)
+	return nil
+}
+
+// Payment methods a listing can accept.
+const (
+	PaymentCash         = "cash"
+	PaymentCard         = "card"
+	PaymentContactless  = "contactless"
+	PaymentOnlinePrepay = "online_prepay"
+	PaymentSNAP         = "snap_ebt"
+)
+
+var paymentMethods = map[string]bool{
+	PaymentCash:         true,
+	PaymentCard:         true,
+	PaymentContactless:  true,
+	PaymentOnlinePrepay: true,
+	PaymentSNAP:         true,
+}
+
+// IsPaymentMethod reports whether method is a known payment method.
+func IsPaymentMethod(method string) bool {
+	return paymentMethods[method]
+}
+
+type PaymentMethods []string
+
+func (p PaymentMethods) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+
+	return pq.StringArray(p).Value()
+}
+
+func (p *PaymentMethods) Scan(src interface{}) error {
+	var methods pq.StringArray
+	if err := methods.Scan(src); err != nil {
+		return err
+	}
+
+	if methods == nil {
+		methods = pq.StringArray{}
+	}
+
+	*p = PaymentMethods(methods)
+	return nil
+}
+
+// Ordering channel types. Direct channels send the order straight to the
+// restaurant, so they are ranked ahead of the delivery platforms.
+const (
+	OrderingChannelDirect      = "direct"
+	OrderingChannelPhone       = "phone"
+	OrderingChannelCurbsideApp = "curbside_app"
+	OrderingChannelDoorDash    = "doordash"
+	OrderingChannelUberEats    = "ubereats"
+	OrderingChannelGrubhub     = "grubhub"
+)
+
+var orderingChannelRanks = map[string]int{
+	OrderingChannelDirect:      0,
+	OrderingChannelPhone:       1,
+	OrderingChannelCurbsideApp: 2,
+	OrderingChannelDoorDash:    3,
+	OrderingChannelUberEats:    3,
+	OrderingChannelGrubhub:     3,
+}
+
+// IsOrderingChannel reports whether channelType is a known ordering channel.
+func IsOrderingChannel(channelType string) bool {
+	_, ok := orderingChannelRanks[channelType]
+	return ok
+}
+
+type OrderingChannel struct {
+	Type string `json:"type" validate:"required,oneof=direct phone curbside_app doordash ubereats grubhub"`
+	URL  string `json:"url" validate:"required,url"`
+}
+
+type OrderingChannels []OrderingChannel
+
+// Sort orders the channels so that direct ordering comes first, keeping the
+// submitted order within the same rank.
+func (c OrderingChannels) Sort() {
+	sort.SliceStable(c, func(i, j int) bool {
+		return orderingChannelRanks[c[i].Type] < orderingChannelRanks[c[j].Type]
+	})
+}
+
+func (c OrderingChannels) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+func (c *OrderingChannels) Scan(src interface{}) error {
 	if src == nil {
+		*c = OrderingChannels{}
 		return nil
 	}
-	var psqlArrayStr string
+
+	var source []byte
 	switch src.(type) {
 	case string:
-		psqlArrayStr = src.(string)
+		source = []byte(src.(string))
 	case []byte:
-		psqlArrayStr = string(src.([]byte))
+		source = src.([]byte)
 	default:
-		return errors.New("Incompatible type for RestaurantTags")
+		return errors.New("Incompatible type for OrderingChannels")
 	}
 
-	psqlArrayStr = strings.TrimLeft(psqlArrayStr, "{")
-	psqlArrayStr = strings.TrimRight(psqlArrayStr, "}")
+	if err := json.Unmarshal(source, c); err != nil {
+		return err
+	}
 
-	tags := strings.Split(psqlArrayStr, ",")
+	c.Sort()
+	return nil
+}
 
-	for index, tag := range tags {
-		tags[index] = strings.Trim(tag, "\"")
+// Service modes a listing can offer. These match the boolean columns on
+// businesses and the values accepted by the service filter.
+const (
+	ServiceModeTakeout      = "takeout"
+	ServiceModeCurbside     = "curbside"
+	ServiceModeDelivery     = "delivery"
+	ServiceModeDriveThrough = "drive_through"
+)
+
+type ServiceModes struct {
+	Takeout      bool `db:"takeout" json:"takeout"`
+	Curbside     bool `db:"curbside" json:"curbside"`
+	Delivery     bool `db:"delivery" json:"delivery"`
+	DriveThrough bool `db:"drive_through" json:"drive_through"`
+}
+
+// Any reports whether at least one service mode is offered.
+func (m ServiceModes) Any() bool {
+	return m.Takeout || m.Curbside || m.Delivery || m.DriveThrough
+}
+
+// List returns the names of the offered service modes.
+func (m ServiceModes) List() []string {
+	modes := []string{}
+	if m.Takeout {
+		modes = append(modes, ServiceModeTakeout)
+	}
+	if m.Curbside {
+		modes = append(modes, ServiceModeCurbside)
+	}
+	if m.Delivery {
+		modes = append(modes, ServiceModeDelivery)
+	}
+	if m.DriveThrough {
+		modes = append(modes, ServiceModeDriveThrough)
 	}
 
-	*t = tags
-	return nil
+	return modes
+}
+
+// IsServiceMode reports whether mode is a known service mode.
+func IsServiceMode(mode string) bool {
+	switch mode {
+	case ServiceModeTakeout, ServiceModeCurbside, ServiceModeDelivery, ServiceModeDriveThrough:
+		return true
+	}
+
+	return false
 }
 
 type Restaurant struct {
 	CommonModelFields
-	Name        string         `db:"name" json:"name" validate:"required"`
-	Type        string         `db:"type" json:"type" validate:"required"`
-	Tags        RestaurantTags `db:"tags" json:"tags"`
-	Phone       string         `db:"phone" json:"phone" validate:"required,max=14"`
-	Details     string         `db:"details" json:"details"`
-	Hours       string         `db:"hours" json:"hours"`
-	Email       string         `db:"email" json:"email" validate:"required,email"`
-	URL         string         `db:"url" json:"url" validate:"omitempty,url"`
-	Address     string         `db:"address" json:"address" validate:"required"`
-	Address2    string         `db:"address2" json:"address_2"`
-	City        string         `db:"city" json:"city" validate:"required"`
-	State       string         `db:"state" json:"state" validate:"required"`
-	Zipcode     string         `db:"zipcode" json:"zipcode" validate:"required,len=5"`
-	DonateURL   string         `db:"donate_url" json:"donate_url" validate:"omitempty,url"`
-	Location    string         `db:"location" json:"-"`
-	HasGiftCard bool           `db:"giftcard" json:"giftcard"`
-	IsActive    bool           `db:"is_active" json:"active"`
-	LatLng      GeoPoint       `json:"latlng"`
+	Name             string           `db:"name" json:"name" validate:"required"`
+	Type             string           `db:"type" json:"type" validate:"required"`
+	Tags             RestaurantTags   `db:"tags" json:"tags"`
+	Phone            string           `db:"phone" json:"phone" validate:"required,max=40"`
+	PhoneE164        string           `db:"phone_e164" json:"phone_e164"`
+	PhoneExtension   string           `db:"phone_extension" json:"phone_extension"`
+	PhoneDisplay     string           `db:"-" json:"phone_display"`
+	Details          string           `db:"details" json:"details"`
+	Hours            string           `db:"hours" json:"hours"`
+	Email            string           `db:"email" json:"email" validate:"required,email"`
+	URL              string           `db:"url" json:"url" validate:"omitempty,url"`
+	OrderingChannels OrderingChannels `db:"ordering_channels" json:"ordering_channels" validate:"omitempty,dive"`
+	Address          string           `db:"address" json:"address" validate:"required"`
+	Address2         string           `db:"address2" json:"address_2"`
+	City             string           `db:"city" json:"city" validate:"required"`
+	State            string           `db:"state" json:"state" validate:"max=64"`
+	Zipcode          string           `db:"zipcode" json:"zipcode" validate:"max=12"`
+	Country          string           `db:"country" json:"country" validate:"required,len=2"`
+	DonateURL        string           `db:"donate_url" json:"donate_url" validate:"omitempty,url"`
+	Location         string           `db:"location" json:"-"`
+	HasGiftCard      bool             `db:"giftcard" json:"giftcard"`
+	IsActive         bool             `db:"is_active" json:"active"`
+	IsVerified       bool             `db:"is_verified" json:"verified"`
+	IsHidden         bool             `db:"is_hidden" json:"-"`
+	HiddenBy         *string          `db:"hidden_by" json:"-"`
+	LatLng           GeoPoint         `json:"latlng"`
+	PaymentMethods   PaymentMethods   `db:"payment_methods" json:"payment_methods" validate:"omitempty,unique,dive,oneof=cash card contactless online_prepay snap_ebt"`
+	Attributes       []string         `db:"-" json:"attributes" validate:"omitempty,unique"`
+	Translations     Translations     `db:"-" json:"translations,omitempty"`
+	Photos           []Photo          `db:"-" json:"photos,omitempty"`
+	DuplicateIDs     pq.Int64Array    `db:"duplicate_candidates" json:"-"`
+	MergedInto       *uint            `db:"merged_into" json:"-"`
+	ApprovedAt       *time.Time       `db:"approved_at" json:"-"`
+	ApprovedBy       *string          `db:"approved_by" json:"-"`
+	RejectedAt       *time.Time       `db:"rejected_at" json:"-"`
+	RejectedBy       *string          `db:"rejected_by" json:"-"`
+	RejectionReason  *string          `db:"rejection_reason" json:"-"`
+	Status           *StatusUpdate    `db:"-" json:"status"`
+	LastConfirmedAt  *time.Time       `db:"last_confirmed_at" json:"last_confirmed_at"`
+	OwnerUpdatedAt   *time.Time       `db:"owner_updated_at" json:"-"`
+	Freshness        float64          `db:"-" json:"freshness"`
+	ServiceModes     `json:"service_modes"`
 	CommonModelTimestamps
 }
 
+// DuplicateCandidate is an existing listing that a new submission may
+// duplicate, along with why it matched.
+type DuplicateCandidate struct {
+	ID             uint     `db:"id" json:"id"`
+	Name           string   `db:"name" json:"name"`
+	PhoneMatch     bool     `db:"phone_match" json:"phone_match"`
+	Similarity     float64  `db:"similarity" json:"similarity"`
+	DistanceMeters *float64 `db:"distance" json:"distance_meters"`
+}
+
+// MergeChoices picks, per field, whether a merge keeps the surviving
+// listing's value ("target") or takes the duplicate's ("source").
+type MergeChoices map[string]string
+
+const (
+	MergeKeepTarget = "target"
+	MergeTakeSource = "source"
+)
+
+// RestaurantFilters narrows down the listings returned by GetRestaurants.
+type RestaurantFilters struct {
+	Channels       []string
+	ServiceModes   []string
+	PaymentMethods []string
+	Attributes     []string
+	OpenNow        bool
+	// SortByFreshness ranks recently confirmed or updated listings first
+	// instead of sorting by name.
+	SortByFreshness bool
+}
+
+// NormalizeAddress fills in the default country and canonicalizes the
+// region and postal code so they can be validated and looked up.
+func (r *Restaurant) NormalizeAddress() {
+	r.Country = NormalizeCountry(r.Country)
+	r.State = NormalizeRegion(r.Country, r.State)
+	r.Zipcode = NormalizePostalCode(r.Country, r.Zipcode)
+}
+
+// Localize replaces translatable text with the given translations. Fields
+// without a translation keep the default text.
+func (r *Restaurant) Localize(texts map[string]string) {
+	if details, ok := texts["details"]; ok {
+		r.Details = details
+	}
+	if hours, ok := texts["hours"]; ok {
+		r.Hours = hours
+	}
+}
+
+// NormalizePhone parses the submitted phone number for the listing's country
+// and stores its E.164 form alongside the original input.
+func (r *Restaurant) NormalizePhone() error {
+	phone, err := ParsePhone(r.Phone, r.Country)
+	if err != nil {
+		return err
+	}
+
+	r.Phone = strings.TrimSpace(r.Phone)
+	r.PhoneE164 = phone.E164
+	r.PhoneExtension = phone.Extension
+	r.PhoneDisplay = FormatPhone(r.PhoneE164, r.PhoneExtension)
+
+	return nil
+}
+
 type RestaurantMsg struct {
 	Type string `json:"type"`
 	Text string `json:"text"`
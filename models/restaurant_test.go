@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestaurantTagsRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []RestaurantTags{
+		{"takeout", "curbside"},
+		{"mac, cheese", `the "best"`, "{braces}", `back\slash`},
+		{},
+	}
+
+	for _, tags := range tests {
+		value, err := tags.Value()
+		assert.NoError(err)
+
+		var scanned RestaurantTags
+		assert.NoError(scanned.Scan([]byte(value.(string))))
+		assert.Equal(tags, scanned, value)
+	}
+}
+
+func TestRestaurantTagsScanEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	var tags RestaurantTags
+	assert.NoError(tags.Scan([]byte("{}")))
+	assert.Equal(RestaurantTags{}, tags)
+
+	assert.NoError(tags.Scan(nil))
+	assert.Equal(RestaurantTags{}, tags)
+
+	value, err := RestaurantTags(nil).Value()
+	assert.NoError(err)
+	assert.Equal("{}", value)
+}
+
+func TestSlugifyTag(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("curbside-pickup", SlugifyTag("  Curbside Pickup! "))
+	assert.Equal("drive-thru", SlugifyTag("Drive_Thru"))
+	assert.Equal("", SlugifyTag(" -- "))
+}
+
+func TestPaymentMethodsRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	value, err := PaymentMethods(nil).Value()
+	assert.NoError(err)
+	assert.Equal("{}", value)
+
+	var methods PaymentMethods
+	assert.NoError(methods.Scan([]byte("{cash,snap_ebt}")))
+	assert.Equal(PaymentMethods{PaymentCash, PaymentSNAP}, methods)
+
+	assert.NoError(methods.Scan(nil))
+	assert.Equal(PaymentMethods{}, methods)
+
+	assert.True(IsPaymentMethod(PaymentContactless))
+	assert.False(IsPaymentMethod("bitcoin"))
+}
+
+func TestIsPublic(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+
+	assert.True(Restaurant{IsActive: true}.IsPublic())
+	assert.False(Restaurant{}.IsPublic())
+	assert.False(Restaurant{IsActive: true, RejectedAt: &now}.IsPublic())
+
+	deleted := Restaurant{IsActive: true}
+	deleted.DeletedAt = &now
+	assert.False(deleted.IsPublic())
+}
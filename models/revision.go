@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Revision sources describe how a change was made.
+const (
+	RevisionSourceMerge      = "merge"
+	RevisionSourceClaim      = "claim"
+	RevisionSourceOwner      = "owner"
+	RevisionSourceReports    = "reports"
+	RevisionSourceModeration = "moderation"
+)
+
+type Revision struct {
+	CommonModelFields
+	BusinessID         uint            `db:"business_id" json:"restaurant_id"`
+	OriginalBusinessID uint            `db:"original_business_id" json:"original_restaurant_id"`
+	Source             string          `db:"source" json:"source"`
+	Actor              string          `db:"actor" json:"actor"`
+	Summary            string          `db:"summary" json:"summary"`
+	Changes            RevisionChanges `db:"changes" json:"changes"`
+	CreatedAt          time.Time       `db:"created_at" json:"created_at"`
+}
+
+type RevisionChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type RevisionChanges map[string]RevisionChange
+
+// revisionIgnoredFields are bookkeeping fields left out of revision diffs.
+var revisionIgnoredFields = map[string]bool{
+	"id":                true,
+	"created_at":        true,
+	"updated_at":        true,
+	"deleted_at":        true,
+	"photos":            true,
+	"status":            true,
+	"latlng":            true,
+	"phone_display":     true,
+	"last_confirmed_at": true,
+	"freshness":         true,
+}
+
+// DiffRestaurants compares two versions of a listing by their JSON fields and
+// returns the fields that changed.
+func DiffRestaurants(before Restaurant, after Restaurant) RevisionChanges {
+	beforeFields := restaurantFields(before)
+	afterFields := restaurantFields(after)
+
+	changes := RevisionChanges{}
+	for field, afterValue := range afterFields {
+		if revisionIgnoredFields[field] {
+			continue
+		}
+
+		if beforeValue := beforeFields[field]; !reflect.DeepEqual(beforeValue, afterValue) {
+			changes[field] = RevisionChange{
+				Before: beforeValue,
+				After:  afterValue,
+			}
+		}
+	}
+
+	return changes
+}
+
+func restaurantFields(restaurant Restaurant) map[string]interface{} {
+	fields := map[string]interface{}{}
+	encoded, _ := json.Marshal(restaurant)
+	json.Unmarshal(encoded, &fields)
+
+	return fields
+}
+
+func (c RevisionChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+func (c *RevisionChanges) Scan(src interface{}) error {
+	var source []byte
+	switch src.(type) {
+	case string:
+		source = []byte(src.(string))
+	case []byte:
+		source = src.([]byte)
+	case nil:
+		*c = RevisionChanges{}
+		return nil
+	default:
+		return errors.New("Incompatible type for RevisionChanges")
+	}
+
+	return json.Unmarshal(source, c)
+}
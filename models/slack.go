@@ -1,12 +1,27 @@
 package models
 
+// Action IDs attached to interactive message buttons.
+const (
+	SlackActionApproveRestaurant = "approve_restaurant"
+	SlackActionRejectRestaurant  = "reject_restaurant"
+	SlackActionEditRestaurant    = "edit_restaurant"
+	SlackActionApprovePhoto      = "approve_photo"
+	SlackActionApproveStatus     = "approve_status"
+	SlackActionApproveEdit       = "approve_edit"
+	SlackActionRejectEdit        = "reject_edit"
+	SlackActionHideReport        = "hide_report"
+	SlackActionDismissReport     = "dismiss_report"
+)
+
 type SlackWebhookPost struct {
 	Type        string              `json:"type"`
 	Team        SlackTeam           `json:"team"`
 	User        SlackUser           `json:"user"`
 	Channel     SlackChannel        `json:"channel"`
 	ResponseURL string              `json:"response_url"`
+	TriggerID   string              `json:"trigger_id"`
 	Actions     []SlackPostedAction `json:"actions"`
+	View        *SlackView          `json:"view"`
 }
 
 type SlackTeam struct {
@@ -27,8 +42,10 @@ type SlackChannel struct {
 }
 
 type SlackPostedAction struct {
-	Value string       `json:"value"`
-	Text  SlackMsgText `json:"text"`
+	ActionID       string          `json:"action_id"`
+	Value          string          `json:"value"`
+	Text           SlackMsgText    `json:"text"`
+	SelectedOption *SlackMsgOption `json:"selected_option"`
 }
 
 type SlackMsg struct {
@@ -37,9 +54,15 @@ type SlackMsg struct {
 
 type SlackMsgBlock struct {
 	Type     string            `json:"type"`
+	BlockID  string            `json:"block_id,omitempty"`
 	Text     *SlackMsgText     `json:"text,omitempty"`
 	Fields   *[]SlackMsgText   `json:"fields,omitempty"`
 	Elements *[]SlackMsgAction `json:"elements,omitempty"`
+	ImageURL string            `json:"image_url,omitempty"`
+	AltText  string            `json:"alt_text,omitempty"`
+	Label    *SlackMsgText     `json:"label,omitempty"`
+	Element  *SlackMsgAction   `json:"element,omitempty"`
+	Optional bool              `json:"optional,omitempty"`
 }
 
 type SlackMsgText struct {
@@ -47,9 +70,67 @@ type SlackMsgText struct {
 	Text string `json:"text"`
 }
 
+// SlackMsgAction is an interactive element: a button, or a select menu with
+// a placeholder and options.
 type SlackMsgAction struct {
-	Type  string       `json:"type"`
+	ActionID     string           `json:"action_id,omitempty"`
+	Type         string           `json:"type"`
+	Text         *SlackMsgText    `json:"text,omitempty"`
+	Style        string           `json:"style,omitempty"`
+	Value        string           `json:"value,omitempty"`
+	Placeholder  *SlackMsgText    `json:"placeholder,omitempty"`
+	Options      []SlackMsgOption `json:"options,omitempty"`
+	InitialValue string           `json:"initial_value,omitempty"`
+	Multiline    bool             `json:"multiline,omitempty"`
+}
+
+type SlackMsgOption struct {
 	Text  SlackMsgText `json:"text"`
-	Style string       `json:"style"`
 	Value string       `json:"value"`
 }
+
+// SlackView is a modal. Slack sends it back with the entered values in its
+// state when the modal is submitted.
+type SlackView struct {
+	Type            string          `json:"type"`
+	CallbackID      string          `json:"callback_id,omitempty"`
+	PrivateMetadata string          `json:"private_metadata,omitempty"`
+	Title           *SlackMsgText   `json:"title,omitempty"`
+	Submit          *SlackMsgText   `json:"submit,omitempty"`
+	Close           *SlackMsgText   `json:"close,omitempty"`
+	Blocks          []SlackMsgBlock `json:"blocks"`
+	State           *SlackViewState `json:"state,omitempty"`
+}
+
+// SlackViewState holds submitted values by block id and then action id.
+type SlackViewState struct {
+	Values map[string]map[string]SlackViewValue `json:"values"`
+}
+
+type SlackViewValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// SlackViewErrors is the response that shows validation errors next to the
+// modal's inputs, keyed by block id.
+type SlackViewErrors struct {
+	ResponseAction string            `json:"response_action"`
+	Errors         map[string]string `json:"errors"`
+}
+
+// SlackResponseMsg is posted to an interaction's response URL. With
+// ReplaceOriginal set it replaces the message that was acted on.
+type SlackResponseMsg struct {
+	ReplaceOriginal bool            `json:"replace_original"`
+	Text            string          `json:"text"`
+	Blocks          []SlackMsgBlock `json:"blocks,omitempty"`
+}
+
+// SlackCommandResponse answers a slash command. Ephemeral responses are only
+// shown to the person who ran the command.
+type SlackCommandResponse struct {
+	ResponseType string          `json:"response_type"`
+	Text         string          `json:"text"`
+	Blocks       []SlackMsgBlock `json:"blocks,omitempty"`
+}
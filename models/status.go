@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Status update types. A temporary closure hides the listing from open_now
+// results until it ends.
+const (
+	StatusTemporarilyClosed = "temporarily_closed"
+	StatusLimitedMenu       = "limited_menu"
+	StatusSpecial           = "special"
+)
+
+type StatusUpdate struct {
+	CommonModelFields
+	BusinessID   uint         `db:"business_id" json:"restaurant_id"`
+	Type         string       `db:"type" json:"type" validate:"required,oneof=temporarily_closed limited_menu special"`
+	Message      string       `db:"message" json:"message" validate:"required,max=500"`
+	StartsAt     time.Time    `db:"starts_at" json:"starts_at"`
+	EndsAt       time.Time    `db:"ends_at" json:"ends_at" validate:"required"`
+	IsApproved   bool         `db:"is_approved" json:"-"`
+	ApprovedAt   *time.Time   `db:"approved_at" json:"-"`
+	ApprovedBy   *string      `db:"approved_by" json:"-"`
+	Translations Translations `db:"-" json:"translations,omitempty"`
+	CommonModelTimestamps
+}
+
+// Localize replaces the message with its translation, if there is one.
+func (s *StatusUpdate) Localize(texts map[string]string) {
+	if message, ok := texts["message"]; ok {
+		s.Message = message
+	}
+}
+
+// IsActiveAt reports whether an approved update has started and not yet
+// expired.
+func (s StatusUpdate) IsActiveAt(now time.Time) bool {
+	return s.IsApproved && s.DeletedAt == nil && !s.StartsAt.After(now) && s.EndsAt.After(now)
+}
+
+// ActiveStatus picks the status to show for a listing: a closure wins over
+// other updates, and otherwise the most recently started one. It returns nil
+// if none are active.
+func ActiveStatus(statuses []StatusUpdate, now time.Time) *StatusUpdate {
+	var active *StatusUpdate
+
+	for i := range statuses {
+		status := statuses[i]
+		if !status.IsActiveAt(now) {
+			continue
+		}
+
+		if active == nil {
+			active = &status
+			continue
+		}
+
+		closed := status.Type == StatusTemporarilyClosed
+		activeClosed := active.Type == StatusTemporarilyClosed
+		if (closed && !activeClosed) || (closed == activeClosed && status.StartsAt.After(active.StartsAt)) {
+			active = &status
+		}
+	}
+
+	return active
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testStatus(statusType string, startedAgo time.Duration, endsIn time.Duration, now time.Time) StatusUpdate {
+	return StatusUpdate{
+		Type:       statusType,
+		StartsAt:   now.Add(-startedAgo),
+		EndsAt:     now.Add(endsIn),
+		IsApproved: true,
+	}
+}
+
+func TestStatusIsActiveAt(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+
+	assert.True(testStatus(StatusSpecial, time.Hour, time.Hour, now).IsActiveAt(now))
+
+	// expired, not started yet, and unapproved updates are not shown
+	assert.False(testStatus(StatusSpecial, 2*time.Hour, -time.Hour, now).IsActiveAt(now))
+	assert.False(testStatus(StatusSpecial, -time.Hour, 2*time.Hour, now).IsActiveAt(now))
+
+	pending := testStatus(StatusSpecial, time.Hour, time.Hour, now)
+	pending.IsApproved = false
+	assert.False(pending.IsActiveAt(now))
+
+	deleted := testStatus(StatusSpecial, time.Hour, time.Hour, now)
+	deleted.DeletedAt = &now
+	assert.False(deleted.IsActiveAt(now))
+}
+
+func TestActiveStatusPrefersClosures(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+
+	closed := testStatus(StatusTemporarilyClosed, 3*time.Hour, time.Hour, now)
+	menu := testStatus(StatusLimitedMenu, time.Hour, time.Hour, now)
+	special := testStatus(StatusSpecial, 2*time.Hour, time.Hour, now)
+
+	// a closure wins even over updates that started after it
+	active := ActiveStatus([]StatusUpdate{menu, closed, special}, now)
+	if assert.NotNil(active) {
+		assert.Equal(StatusTemporarilyClosed, active.Type)
+	}
+
+	// otherwise the most recently started update is shown
+	active = ActiveStatus([]StatusUpdate{special, menu}, now)
+	if assert.NotNil(active) {
+		assert.Equal(StatusLimitedMenu, active.Type)
+	}
+
+	// an expired closure no longer counts
+	expired := testStatus(StatusTemporarilyClosed, 3*time.Hour, -time.Hour, now)
+	active = ActiveStatus([]StatusUpdate{expired, special}, now)
+	if assert.NotNil(active) {
+		assert.Equal(StatusSpecial, active.Type)
+	}
+
+	assert.Nil(ActiveStatus([]StatusUpdate{expired}, now))
+	assert.Nil(ActiveStatus(nil, now))
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Submission statuses as moderators see them.
+const (
+	SubmissionPending  = "pending"
+	SubmissionApproved = "approved"
+	SubmissionHidden   = "hidden"
+	SubmissionRejected = "rejected"
+)
+
+// IsSubmissionStatus reports whether status is a known submission status.
+func IsSubmissionStatus(status string) bool {
+	switch status {
+	case SubmissionPending, SubmissionApproved, SubmissionHidden, SubmissionRejected:
+		return true
+	}
+
+	return false
+}
+
+// SubmissionStatus describes where a listing is in moderation.
+func (r Restaurant) SubmissionStatus() string {
+	switch {
+	case r.RejectedAt != nil:
+		return SubmissionRejected
+	case r.IsHidden:
+		return SubmissionHidden
+	case r.IsActive:
+		return SubmissionApproved
+	}
+
+	return SubmissionPending
+}
+
+// IsPublic reports whether a listing is approved and still live, which is
+// what visitors need before they can report, photograph, update, suggest
+// edits to or claim it.
+func (r Restaurant) IsPublic() bool {
+	return r.DeletedAt == nil && r.RejectedAt == nil && r.IsActive
+}
+
+// Submission is a listing along with its moderation history, as shown to
+// admins.
+type Submission struct {
+	Restaurant
+	Status          string     `json:"status"`
+	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
+	ApprovedBy      *string    `json:"approved_by,omitempty"`
+	RejectedAt      *time.Time `json:"rejected_at,omitempty"`
+	RejectedBy      *string    `json:"rejected_by,omitempty"`
+	RejectionReason *string    `json:"rejection_reason,omitempty"`
+	HiddenBy        *string    `json:"hidden_by,omitempty"`
+}
+
+func NewSubmission(r Restaurant) Submission {
+	return Submission{
+		Restaurant:      r,
+		Status:          r.SubmissionStatus(),
+		ApprovedAt:      r.ApprovedAt,
+		ApprovedBy:      r.ApprovedBy,
+		RejectedAt:      r.RejectedAt,
+		RejectedBy:      r.RejectedBy,
+		RejectionReason: r.RejectionReason,
+		HiddenBy:        r.HiddenBy,
+	}
+}
+
+// ModerationStats summarizes the moderation queues.
+type ModerationStats struct {
+	Pending      int `db:"pending" json:"pending"`
+	Approved     int `db:"approved" json:"approved"`
+	Hidden       int `db:"hidden" json:"hidden"`
+	Rejected     int `db:"rejected" json:"rejected"`
+	OpenReports  int `db:"open_reports" json:"open_reports"`
+	PendingEdits int `db:"pending_edits" json:"pending_edits"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var tagSlugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+type Tag struct {
+	CommonModelFields
+	Slug        string         `db:"slug" json:"slug"`
+	DisplayName string         `db:"display_name" json:"display_name" validate:"required"`
+	Synonyms    pq.StringArray `db:"synonyms" json:"synonyms"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// SlugifyTag lowercases a submitted tag and collapses anything that is not a
+// letter or digit into single hyphens, so "Curbside Pickup!" becomes
+// "curbside-pickup".
+func SlugifyTag(tag string) string {
+	slug := tagSlugInvalidChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(tag)), "-")
+	return strings.Trim(slug, "-")
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the language listing text is submitted in. Translations
+// are only stored for the other supported locales.
+const DefaultLocale = "en"
+
+var SupportedLocales = []string{DefaultLocale, "es", "vi"}
+
+// Translation entity types.
+const (
+	TranslationBusiness     = "business"
+	TranslationStatusUpdate = "status_update"
+)
+
+const maxTranslationLength = 2000
+
+// Translatable fields by entity.
+var (
+	TranslatableRestaurantFields = []string{"details", "hours"}
+	TranslatableStatusFields     = []string{"message"}
+)
+
+// Translations holds translated text by locale and then by field, such as
+// {"es": {"hours": "..."}}. An empty text removes a translation.
+type Translations map[string]map[string]string
+
+// Validate checks that every locale is supported and every field can be
+// translated.
+func (t Translations) Validate(fields ...string) error {
+	allowed := map[string]bool{}
+	for _, field := range fields {
+		allowed[field] = true
+	}
+
+	for locale, texts := range t {
+		if locale == DefaultLocale || !IsSupportedLocale(locale) {
+			return fmt.Errorf("unsupported locale %q", locale)
+		}
+
+		for field, text := range texts {
+			if !allowed[field] {
+				return fmt.Errorf("%q cannot be translated", field)
+			}
+			if len(text) > maxTranslationLength {
+				return fmt.Errorf("%s translation of %q is too long", locale, field)
+			}
+		}
+	}
+
+	return nil
+}
+
+func IsSupportedLocale(locale string) bool {
+	for _, supported := range SupportedLocales {
+		if locale == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchLocale picks the locale to serve. An explicit lang parameter wins,
+// then the highest weighted supported language in the Accept-Language
+// header, then the default.
+func MatchLocale(lang string, acceptLanguage string) string {
+	if locale := baseLanguage(lang); IsSupportedLocale(locale) {
+		return locale
+	}
+
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	candidates := []weighted{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		pieces := strings.Split(part, ";")
+		candidate := weighted{locale: baseLanguage(pieces[0]), q: 1}
+
+		for _, param := range pieces[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					candidate.q = q
+				}
+			}
+		}
+
+		if candidate.q > 0 && IsSupportedLocale(candidate.locale) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	if len(candidates) > 0 {
+		return candidates[0].locale
+	}
+
+	return DefaultLocale
+}
+
+// baseLanguage reduces a language tag like "es-MX" to "es".
+func baseLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0]
+}
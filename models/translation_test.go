@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchLocale(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		lang           string
+		acceptLanguage string
+		expected       string
+	}{
+		{"", "", "en"},
+		{"es", "vi", "es"},
+		{"ES-mx", "", "es"},
+		{"fr", "vi-VN,vi;q=0.9", "vi"},
+		{"", "fr-CA, es;q=0.5, vi;q=0.8", "vi"},
+		{"", "es-MX;q=0.9, en;q=0.8", "es"},
+		{"", "de, fr;q=0.9", "en"},
+		{"", "vi;q=0, es;q=0.1", "es"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(tc.expected, MatchLocale(tc.lang, tc.acceptLanguage), "%q %q", tc.lang, tc.acceptLanguage)
+	}
+}
+
+func TestTranslationsValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(Translations{"es": {"details": "Hola", "hours": ""}}.Validate(TranslatableRestaurantFields...))
+	assert.NoError(Translations(nil).Validate(TranslatableRestaurantFields...))
+	assert.Error(Translations{"en": {"details": "Hello"}}.Validate(TranslatableRestaurantFields...))
+	assert.Error(Translations{"fr": {"details": "Bonjour"}}.Validate(TranslatableRestaurantFields...))
+	assert.Error(Translations{"es": {"message": "Cerrado"}}.Validate(TranslatableRestaurantFields...))
+	assert.NoError(Translations{"vi": {"message": "Đóng cửa"}}.Validate(TranslatableStatusFields...))
+}
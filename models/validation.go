@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/go-playground/validator"
+)
+
+// NewValidator returns a validator with the struct tag rules plus the checks
+// that depend on more than one field, such as per-country address rules.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterStructValidation(restaurantStructLevelValidation, Restaurant{})
+
+	return validate
+}
+
+func restaurantStructLevelValidation(sl validator.StructLevel) {
+	restaurant := sl.Current().Interface().(Restaurant)
+
+	if restaurant.Phone != "" {
+		if _, err := ParsePhone(restaurant.Phone, restaurant.Country); err != nil {
+			sl.ReportError(restaurant.Phone, "Phone", "Phone", "phone", restaurant.Country)
+		}
+	}
+
+	for _, field := range InvalidAddressFields(restaurant.Country, restaurant.State, restaurant.Zipcode) {
+		switch field {
+		case "Country":
+			sl.ReportError(restaurant.Country, field, field, "iso3166_1_alpha2", "")
+		case "State":
+			sl.ReportError(restaurant.State, field, field, "region", restaurant.Country)
+		case "Zipcode":
+			sl.ReportError(restaurant.Zipcode, field, field, "postal_code", restaurant.Country)
+		}
+	}
+}
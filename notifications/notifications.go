@@ -0,0 +1,95 @@
+package notifications
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Helvetica, Arial, sans-serif;">
+{{if .Done}}<p>{{.Email}} will no longer receive emails about submissions.</p>
+{{else}}<form method="POST">
+<p>Stop sending emails about submissions to {{.Email}}?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}</body>
+</html>
+`))
+
+type Controller struct {
+	r      *mux.Router
+	ue     services.UnsubscribeEntityInterface
+	signer services.TokenSigner
+}
+
+func NewController(router *mux.Router, uei services.UnsubscribeEntityInterface, signer services.TokenSigner) Controller {
+	c := Controller{
+		r:      router,
+		ue:     uei,
+		signer: signer,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/notifications").Subrouter()
+	s.HandleFunc("/unsubscribe", c.confirm).Methods("GET")
+	s.HandleFunc("/unsubscribe", c.unsubscribe).Methods("POST")
+}
+
+// confirm asks before unsubscribing, so mail scanners that follow links don't
+// unsubscribe anyone.
+func (c *Controller) confirm(w http.ResponseWriter, r *http.Request) {
+	claims, ok := c.verify(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	unsubscribePage.Execute(w, map[string]interface{}{"Email": claims.Email, "Done": false})
+	return
+}
+
+// unsubscribe handles both the confirmation form and one-click unsubscribe
+// requests from mail clients.
+func (c *Controller) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	claims, ok := c.verify(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.ue.Unsubscribe(claims.Email); err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem unsubscribing you: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	unsubscribePage.Execute(w, map[string]interface{}{"Email": claims.Email, "Done": true})
+	return
+}
+
+func (c *Controller) verify(w http.ResponseWriter, r *http.Request) (*services.TokenClaims, bool) {
+	claims, err := c.signer.Verify(r.URL.Query().Get("token"), services.TokenPurposeUnsubscribe)
+	if err == services.ErrExpiredToken {
+		http.Error(w, "This unsubscribe link has expired", http.StatusGone)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "This unsubscribe link is not valid", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return claims, true
+}
@@ -0,0 +1,141 @@
+package owners
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type loginRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type sessionResponse struct {
+	Token         string    `json:"token"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	RestaurantIDs []uint    `json:"restaurant_ids"`
+}
+
+// login emails a magic link to verified owners. It answers the same way
+// whether or not the address owns anything, so it can't be used to probe for
+// owners.
+func (c *Controller) login(w http.ResponseWriter, r *http.Request) {
+	if !c.limiter.Allow(services.ClientIP(r)) {
+		http.Error(w, "Too many logins, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	req := new(loginRequest)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := models.NewValidator().Struct(req); err != nil {
+		http.Error(w, "Could not start login as it contained missing or invalid fields: Email, ", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	ids, err := c.oe.GetOwnedRestaurantIDs(email)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem starting your login: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if len(ids) > 0 {
+		token, err := c.signer.Sign(services.TokenClaims{
+			Purpose:   services.TokenPurposeLogin,
+			Email:     email,
+			ExpiresAt: time.Now().Add(LoginTokenTTL).Unix(),
+		})
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem starting your login: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+
+		link := fmt.Sprintf("%s/owners/login/verify?token=%s", c.baseURL, url.QueryEscape(token))
+
+		err = c.mailer.Send(models.Email{
+			To:      email,
+			Subject: "Your We're Open for Takeout login link",
+			Text: fmt.Sprintf(
+				"Open this link within 15 minutes to manage your listings:\n%s\n\n"+
+					"If you didn't ask to log in, you can ignore this email.\n",
+				link,
+			),
+		})
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem sending your login email: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"status":"login_sent"}` + "\n"))
+	return
+}
+
+// session exchanges a login link for a short-lived session token scoped to
+// the listings the owner has at that moment.
+func (c *Controller) session(w http.ResponseWriter, r *http.Request) {
+	claims, err := c.signer.Verify(r.URL.Query().Get("token"), services.TokenPurposeLogin)
+	if err == services.ErrExpiredToken {
+		http.Error(w, "This login link has expired, please request a new one", http.StatusGone)
+		return
+	}
+	if err != nil {
+		http.Error(w, "This login link is not valid", http.StatusBadRequest)
+		return
+	}
+
+	ids, err := c.oe.GetOwnedRestaurantIDs(claims.Email)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem logging you in: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if len(ids) == 0 {
+		http.Error(w, "This address no longer manages any listings", http.StatusForbidden)
+		return
+	}
+
+	expiresAt := time.Now().Add(SessionTTL)
+	token, err := c.signer.Sign(services.TokenClaims{
+		Purpose:       services.TokenPurposeSession,
+		RestaurantIDs: ids,
+		Email:         claims.Email,
+		ExpiresAt:     expiresAt.Unix(),
+	})
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem logging you in: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	payload, _ := json.Marshal(sessionResponse{
+		Token:         token,
+		ExpiresAt:     expiresAt,
+		RestaurantIDs: ids,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+	return
+}
@@ -0,0 +1,273 @@
+package owners
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+// Each address can ask for a handful of claim and login emails an hour.
+const (
+	EmailLimit       = 5
+	EmailLimitWindow = time.Hour
+)
+
+const (
+	ClaimTokenTTL = 24 * time.Hour
+	LoginTokenTTL = 15 * time.Minute
+	SessionTTL    = 2 * time.Hour
+)
+
+// freeMailDomains are shared mail providers. A listing email at one of these
+// only matches exactly, never by domain.
+var freeMailDomains = map[string]bool{
+	"gmail.com":      true,
+	"googlemail.com": true,
+	"yahoo.com":      true,
+	"ymail.com":      true,
+	"rocketmail.com": true,
+	"hotmail.com":    true,
+	"outlook.com":    true,
+	"live.com":       true,
+	"msn.com":        true,
+	"aol.com":        true,
+	"icloud.com":     true,
+	"me.com":         true,
+	"mac.com":        true,
+	"protonmail.com": true,
+	"proton.me":      true,
+	"pm.me":          true,
+	"gmx.com":        true,
+	"gmx.net":        true,
+	"gmx.de":         true,
+	"web.de":         true,
+	"mail.com":       true,
+	"zoho.com":       true,
+	"yandex.com":     true,
+	"yandex.ru":      true,
+	"fastmail.com":   true,
+	"tutanota.com":   true,
+	"hey.com":        true,
+	"qq.com":         true,
+	"163.com":        true,
+	"naver.com":      true,
+	"comcast.net":    true,
+	"verizon.net":    true,
+	"att.net":        true,
+	"sbcglobal.net":  true,
+	"bellsouth.net":  true,
+	"charter.net":    true,
+	"cox.net":        true,
+	"earthlink.net":  true,
+	"shaw.ca":        true,
+	"rogers.com":     true,
+	"sympatico.ca":   true,
+	"btinternet.com": true,
+	"sky.com":        true,
+	"yahoo.co.uk":    true,
+	"hotmail.co.uk":  true,
+	"yahoo.ca":       true,
+	"hotmail.ca":     true,
+}
+
+type claimRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type Controller struct {
+	r        *mux.Router
+	e        services.RestaurantEntityInterface
+	oe       services.OwnerEntityInterface
+	ee       services.EditEntityInterface
+	geocoder services.GeocodioServiceInterface
+	mailer   services.Mailer
+	signer   services.TokenSigner
+	auth     services.OwnerAuth
+	limiter  *services.RateLimiter
+	baseURL  string
+}
+
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, oei services.OwnerEntityInterface, eei services.EditEntityInterface, geocoder services.GeocodioServiceInterface, mailer services.Mailer, signer services.TokenSigner, limiter *services.RateLimiter, baseURL string) Controller {
+	c := Controller{
+		r:        router,
+		e:        ei,
+		oe:       oei,
+		ee:       eei,
+		geocoder: geocoder,
+		mailer:   mailer,
+		signer:   signer,
+		auth:     services.OwnerAuth{Signer: signer},
+		limiter:  limiter,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/restaurants").Subrouter()
+	s.HandleFunc("/{id}/claim", c.claim).Methods("POST")
+	s.HandleFunc("/{id}/claim/verify", c.verify).Methods("GET")
+
+	o := c.r.PathPrefix("/owners").Subrouter()
+	o.HandleFunc("/login", c.login).Methods("POST")
+	o.HandleFunc("/login/verify", c.session).Methods("GET")
+
+	owned := o.PathPrefix("/restaurants").Subrouter()
+	owned.Use(c.auth.Middleware)
+	owned.HandleFunc("/{id}", c.update).Methods("PATCH")
+}
+
+func (c *Controller) claim(w http.ResponseWriter, r *http.Request) {
+	if !c.limiter.Allow(services.ClientIP(r)) {
+		http.Error(w, "Too many claims, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	restaurant, ok := c.loadRestaurant(w, r)
+	if !ok {
+		return
+	}
+
+	req := new(claimRequest)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := models.NewValidator().Struct(req); err != nil {
+		http.Error(w, "Could not start claim as it contained missing or invalid fields: Email, ", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if !canClaim(*restaurant, email) {
+		http.Error(w, "Claims must use the listing's email address or another address at its domain", http.StatusForbidden)
+		return
+	}
+
+	token, err := c.signer.Sign(services.TokenClaims{
+		Purpose:      services.TokenPurposeClaim,
+		RestaurantID: restaurant.ID,
+		Email:        email,
+		ExpiresAt:    time.Now().Add(ClaimTokenTTL).Unix(),
+	})
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem starting your claim: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	link := fmt.Sprintf("%s/restaurants/%d/claim/verify?token=%s", c.baseURL, restaurant.ID, url.QueryEscape(token))
+
+	err = c.mailer.Send(models.Email{
+		To:      email,
+		Subject: fmt.Sprintf("Verify your claim of %s", restaurant.Name),
+		Text: fmt.Sprintf(
+			"Someone asked to manage the listing for %s on We're Open for Takeout using this address.\n\n"+
+				"If that was you, confirm within 24 hours by opening this link:\n%s\n\n"+
+				"If it wasn't, you can ignore this email.\n",
+			restaurant.Name,
+			link,
+		),
+	})
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem sending your verification email: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"status":"verification_sent"}` + "\n"))
+	return
+}
+
+func (c *Controller) verify(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := c.loadRestaurant(w, r)
+	if !ok {
+		return
+	}
+
+	claims, err := c.signer.Verify(r.URL.Query().Get("token"), services.TokenPurposeClaim)
+	if err == services.ErrExpiredToken {
+		http.Error(w, "This verification link has expired, please start your claim again", http.StatusGone)
+		return
+	}
+	if err != nil || claims.RestaurantID != restaurant.ID {
+		http.Error(w, "This verification link is not valid", http.StatusBadRequest)
+		return
+	}
+
+	owner, err := c.oe.CreateOwner(restaurant.ID, claims.Email)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem verifying your claim: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	payload, _ := json.Marshal(owner)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+	return
+}
+
+func (c *Controller) loadRestaurant(w http.ResponseWriter, r *http.Request) (*models.Restaurant, bool) {
+	restID, err := strconv.ParseUint(strings.TrimSpace(mux.Vars(r)["id"]), 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem loading the restaurant: ID: %v", eventID), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if restaurant == nil || !restaurant.IsPublic() {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	return restaurant, true
+}
+
+// canClaim accepts the listing's own email address, or any address at the
+// domain of the listing's (non-webmail) email. The website isn't used, since
+// it often points at a social or ordering platform anyone can have an
+// address at.
+func canClaim(restaurant models.Restaurant, email string) bool {
+	listingEmail := strings.ToLower(strings.TrimSpace(restaurant.Email))
+	if listingEmail == "" {
+		return false
+	}
+	if listingEmail == email {
+		return true
+	}
+
+	at := strings.LastIndex(email, "@")
+	listingAt := strings.LastIndex(listingEmail, "@")
+	if at < 0 || listingAt < 0 {
+		return false
+	}
+
+	listingDomain := listingEmail[listingAt+1:]
+	return !freeMailDomains[listingDomain] && listingDomain == email[at+1:]
+}
@@ -0,0 +1,487 @@
+package owners
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type mockEntityInterface struct {
+	services.RestaurantEntityInterface
+	testRest *models.Restaurant
+	updates  []models.RestaurantUpdate
+}
+
+func (e *mockEntityInterface) GetRestaurant(id uint) (*models.Restaurant, error) {
+	if e.testRest == nil || e.testRest.ID != id {
+		return nil, nil
+	}
+
+	return e.testRest, nil
+}
+
+func (e *mockEntityInterface) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	if e.testRest == nil || e.testRest.ID != id {
+		return nil, nil
+	}
+
+	e.updates = append(e.updates, update)
+
+	updated := *e.testRest
+	update.Apply(&updated)
+	return &updated, nil
+}
+
+type mockEditEntity struct {
+	edits []models.BusinessEdit
+}
+
+func (e *mockEditEntity) CreateEdit(newEdit models.BusinessEdit, restaurant models.Restaurant) (*uint, error) {
+	e.edits = append(e.edits, newEdit)
+	id := uint(len(e.edits))
+	return &id, nil
+}
+
+func (e *mockEditEntity) ApproveEdit(editID uint, reviewer string) (*models.BusinessEdit, error) {
+	return nil, nil
+}
+
+func (e *mockEditEntity) RejectEdit(editID uint, reviewer string) (*models.BusinessEdit, error) {
+	return nil, nil
+}
+
+type mockGeocoder struct{}
+
+func (g mockGeocoder) GeocodeAddress(street, city, state, zipcode, country string) (*models.GeoPoint, error) {
+	return &models.GeoPoint{Lat: 43.5, Lng: -96.7}, nil
+}
+
+func (g mockGeocoder) GeocodeZipcode(country, zipcode string) (*models.GeoPoint, error) {
+	return &models.GeoPoint{Lat: 43.5, Lng: -96.7}, nil
+}
+
+type mockOwnerEntity struct {
+	owners []models.Owner
+	fail   bool
+}
+
+func (e *mockOwnerEntity) CreateOwner(restaurantID uint, email string) (*models.Owner, error) {
+	if e.fail {
+		return nil, errors.New("could not save owner")
+	}
+
+	owner := models.Owner{BusinessID: restaurantID, Email: email}
+	owner.ID = uint(len(e.owners) + 1)
+	e.owners = append(e.owners, owner)
+
+	return &owner, nil
+}
+
+func (e *mockOwnerEntity) GetOwnedRestaurantIDs(email string) ([]uint, error) {
+	ids := []uint{}
+	for _, owner := range e.owners {
+		if owner.Email == email {
+			ids = append(ids, owner.BusinessID)
+		}
+	}
+
+	return ids, nil
+}
+
+var testSigner = services.TokenSigner{Secret: []byte("test-secret")}
+
+func testRestaurant() *models.Restaurant {
+	restaurant := &models.Restaurant{
+		Name:    "Bob's Burgers",
+		Type:    "Burgers",
+		Phone:   "(605) 555-0134",
+		Email:   "bob@gmail.com",
+		URL:     "https://www.bobsburgers.example.com/menu",
+		Address: "123 Ocean Ave",
+		City:    "Sioux Falls",
+		State:   "SD",
+		Zipcode: "57104",
+		Country: "US",
+	}
+	restaurant.ID = 4
+	restaurant.Takeout = true
+	restaurant.IsActive = true
+
+	return restaurant
+}
+
+func buildController(mailer services.Mailer, oe services.OwnerEntityInterface) Controller {
+	return buildLimitedController(mailer, oe, 100)
+}
+
+func buildLimitedController(mailer services.Mailer, oe services.OwnerEntityInterface, limit int) Controller {
+	return NewController(mux.NewRouter(), &mockEntityInterface{testRest: testRestaurant()}, oe, &mockEditEntity{}, mockGeocoder{}, mailer, testSigner, services.NewRateLimiter(limit, time.Hour), "https://api.example.com/")
+}
+
+func serve(c Controller, method string, target string, body string) *httptest.ResponseRecorder {
+	return serveAs(c, "", method, target, body)
+}
+
+func serveAs(c Controller, token string, method string, target string, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	c.r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestClaimSendsVerificationLink(t *testing.T) {
+	assert := assert.New(t)
+
+	mailer := &services.MemoryMailer{}
+	oe := &mockOwnerEntity{}
+	c := buildController(mailer, oe)
+
+	rr := serve(c, "POST", "/restaurants/4/claim", `{"email": "Bob@Gmail.com"}`)
+	assert.Equal(http.StatusAccepted, rr.Code)
+
+	messages := mailer.Messages()
+	if !assert.Len(messages, 1) {
+		return
+	}
+	assert.Equal("bob@gmail.com", messages[0].To)
+
+	link := regexp.MustCompile(`https://api\.example\.com/restaurants/4/claim/verify\?token=\S+`).FindString(messages[0].Text)
+	if !assert.NotEmpty(link) {
+		return
+	}
+
+	linkURL, _ := url.Parse(link)
+	rr = serve(c, "GET", linkURL.RequestURI(), "")
+	assert.Equal(http.StatusOK, rr.Code)
+
+	var owner models.Owner
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &owner))
+	assert.Equal(uint(4), owner.BusinessID)
+	assert.Equal("bob@gmail.com", owner.Email)
+	assert.Len(oe.owners, 1)
+}
+
+func TestClaimRejectsUnrelatedEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	mailer := &services.MemoryMailer{}
+	c := buildController(mailer, &mockOwnerEntity{})
+
+	// the listing uses a webmail address, so other addresses there don't
+	// count, and neither do addresses at the website's domain
+	for _, email := range []string{"someone@gmail.com", "owner@example.org", "owner@bobsburgers.example.com"} {
+		rr := serve(c, "POST", "/restaurants/4/claim", `{"email": "`+email+`"}`)
+		assert.Equal(http.StatusForbidden, rr.Code, email)
+	}
+
+	rr := serve(c, "POST", "/restaurants/4/claim", `{"email": "bob@gmail.com"}`)
+	assert.Equal(http.StatusAccepted, rr.Code)
+
+	rr = serve(c, "POST", "/restaurants/9/claim", `{"email": "bob@gmail.com"}`)
+	assert.Equal(http.StatusNotFound, rr.Code)
+
+	assert.Len(mailer.Messages(), 1)
+}
+
+func TestClaimRateLimited(t *testing.T) {
+	assert := assert.New(t)
+
+	mailer := &services.MemoryMailer{}
+	c := buildLimitedController(mailer, &mockOwnerEntity{}, 2)
+
+	for i := 0; i < 2; i++ {
+		rr := serve(c, "POST", "/restaurants/4/claim", `{"email": "bob@gmail.com"}`)
+		assert.Equal(http.StatusAccepted, rr.Code)
+	}
+
+	rr := serve(c, "POST", "/restaurants/4/claim", `{"email": "bob@gmail.com"}`)
+	assert.Equal(http.StatusTooManyRequests, rr.Code)
+	assert.Len(mailer.Messages(), 2)
+}
+
+func TestCanClaim(t *testing.T) {
+	tests := []struct {
+		name          string
+		listingEmail  string
+		listingURL    string
+		email         string
+		expectedClaim bool
+	}{
+		{name: "listing email", listingEmail: "Bob@BobsBurgers.com", email: "bob@bobsburgers.com", expectedClaim: true},
+		{name: "listing email domain", listingEmail: "bob@bobsburgers.com", email: "linda@bobsburgers.com", expectedClaim: true},
+		{name: "other domain", listingEmail: "bob@bobsburgers.com", email: "jimmy@pestos.com", expectedClaim: false},
+		{name: "webmail domain", listingEmail: "bob@yahoo.com", email: "jimmy@yahoo.com", expectedClaim: false},
+		{name: "website domain", listingEmail: "bob@gmail.com", listingURL: "https://bobsburgers.com", email: "linda@bobsburgers.com", expectedClaim: false},
+		{name: "platform website", listingURL: "https://www.facebook.com/bobsburgers", email: "anyone@facebook.com", expectedClaim: false},
+		{name: "no listing email", email: "bob@bobsburgers.com", expectedClaim: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			restaurant := models.Restaurant{Email: tc.listingEmail, URL: tc.listingURL}
+			assert.Equal(t, tc.expectedClaim, canClaim(restaurant, tc.email))
+		})
+	}
+}
+
+func TestVerifyRejectsBadTokens(t *testing.T) {
+	assert := assert.New(t)
+
+	oe := &mockOwnerEntity{}
+	c := buildController(&services.MemoryMailer{}, oe)
+
+	expired, _ := testSigner.Sign(services.TokenClaims{
+		Purpose:      services.TokenPurposeClaim,
+		RestaurantID: 4,
+		Email:        "bob@gmail.com",
+		ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+	})
+	otherListing, _ := testSigner.Sign(services.TokenClaims{
+		Purpose:      services.TokenPurposeClaim,
+		RestaurantID: 5,
+		Email:        "bob@gmail.com",
+		ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+	})
+	forged, _ := services.TokenSigner{Secret: []byte("wrong")}.Sign(services.TokenClaims{
+		Purpose:      services.TokenPurposeClaim,
+		RestaurantID: 4,
+		Email:        "bob@gmail.com",
+		ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+	})
+
+	rr := serve(c, "GET", "/restaurants/4/claim/verify?token="+url.QueryEscape(expired), "")
+	assert.Equal(http.StatusGone, rr.Code)
+
+	for _, token := range []string{otherListing, forged, "garbage"} {
+		rr = serve(c, "GET", "/restaurants/4/claim/verify?token="+url.QueryEscape(token), "")
+		assert.Equal(http.StatusBadRequest, rr.Code)
+	}
+
+	assert.Empty(oe.owners)
+}
+
+func TestLoginIssuesScopedSession(t *testing.T) {
+	assert := assert.New(t)
+
+	mailer := &services.MemoryMailer{}
+	oe := &mockOwnerEntity{}
+	oe.CreateOwner(4, "bob@gmail.com")
+	oe.CreateOwner(7, "bob@gmail.com")
+	c := buildController(mailer, oe)
+
+	rr := serve(c, "POST", "/owners/login", `{"email": "nobody@example.org"}`)
+	assert.Equal(http.StatusAccepted, rr.Code)
+	assert.Empty(mailer.Messages())
+
+	rr = serve(c, "POST", "/owners/login", `{"email": "Bob@gmail.com"}`)
+	assert.Equal(http.StatusAccepted, rr.Code)
+
+	messages := mailer.Messages()
+	if !assert.Len(messages, 1) {
+		return
+	}
+
+	link := regexp.MustCompile(`https://api\.example\.com/owners/login/verify\?token=\S+`).FindString(messages[0].Text)
+	if !assert.NotEmpty(link) {
+		return
+	}
+
+	linkURL, _ := url.Parse(link)
+	rr = serve(c, "GET", linkURL.RequestURI(), "")
+	assert.Equal(http.StatusOK, rr.Code)
+
+	var session sessionResponse
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &session))
+	assert.Equal([]uint{4, 7}, session.RestaurantIDs)
+
+	claims, err := testSigner.Verify(session.Token, services.TokenPurposeSession)
+	if assert.NoError(err) {
+		assert.True(claims.Owns(7))
+		assert.False(claims.Owns(5))
+		assert.WithinDuration(time.Now().Add(SessionTTL), time.Unix(claims.ExpiresAt, 0), time.Minute)
+	}
+
+	// a login link can't be used as a session
+	loginToken := linkURL.Query().Get("token")
+	rr = serveAs(c, loginToken, "PATCH", "/owners/restaurants/4", `{"hours": "11-9"}`)
+	assert.Equal(http.StatusUnauthorized, rr.Code)
+}
+
+func TestLoginRateLimited(t *testing.T) {
+	assert := assert.New(t)
+
+	mailer := &services.MemoryMailer{}
+	oe := &mockOwnerEntity{}
+	oe.CreateOwner(4, "bob@gmail.com")
+	c := buildLimitedController(mailer, oe, 2)
+
+	for i := 0; i < 2; i++ {
+		rr := serve(c, "POST", "/owners/login", `{"email": "bob@gmail.com"}`)
+		assert.Equal(http.StatusAccepted, rr.Code)
+	}
+
+	rr := serve(c, "POST", "/owners/login", `{"email": "bob@gmail.com"}`)
+	assert.Equal(http.StatusTooManyRequests, rr.Code)
+	assert.Len(mailer.Messages(), 2)
+}
+
+func ownerSession(restaurantIDs ...uint) string {
+	token, _ := testSigner.Sign(services.TokenClaims{
+		Purpose:       services.TokenPurposeSession,
+		RestaurantIDs: restaurantIDs,
+		Email:         "bob@gmail.com",
+		ExpiresAt:     time.Now().Add(time.Hour).Unix(),
+	})
+
+	return token
+}
+
+func TestOwnerUpdate(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		description   string
+		token         string
+		body          string
+		expectedCode  int
+		expectedBody  string
+		appliedFields []string
+		pendingFields []string
+	}{
+		{
+			description:  "no session",
+			body:         `{"hours": "11-9"}`,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			description:  "expired session",
+			token:        "not-a-token",
+			body:         `{"hours": "11-9"}`,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			description:  "listing owned by someone else",
+			token:        ownerSession(5),
+			body:         `{"hours": "11-9"}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			description:  "empty update",
+			token:        ownerSession(4),
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "invalid field",
+			token:        ownerSession(4),
+			body:         `{"url": "not a url"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "Could not update entry as it contained missing or invalid fields: URL, \n",
+		},
+		{
+			description:  "unknown payment method",
+			token:        ownerSession(4),
+			body:         `{"payment_methods": ["iou"]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:   "translations apply immediately",
+			token:         ownerSession(4),
+			body:          `{"translations": {"es": {"hours": "11 a 21"}}}`,
+			expectedCode:  http.StatusOK,
+			appliedFields: []string{"translations"},
+		},
+		{
+			description:  "unsupported translation locale",
+			token:        ownerSession(4),
+			body:         `{"translations": {"xx": {"hours": "11-9"}}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "clearing the name",
+			token:        ownerSession(4),
+			body:         `{"name": ""}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:  "client supplied location",
+			token:        ownerSession(4),
+			body:         `{"latlng": {"lat": 1, "lng": 2}}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			description:   "low risk fields apply immediately",
+			token:         ownerSession(4),
+			body:          `{"hours": "11-9", "details": "Burger of the day", "service_modes": {"takeout": true, "delivery": true}, "payment_methods": ["card", "contactless"]}`,
+			expectedCode:  http.StatusOK,
+			appliedFields: []string{"hours", "details", "service_modes", "payment_methods"},
+		},
+		{
+			description:   "high risk fields are moderated",
+			token:         ownerSession(4),
+			body:          `{"name": "Bob's Burgers & Fries", "address": "1 Wharf St"}`,
+			expectedCode:  http.StatusAccepted,
+			pendingFields: []string{"name", "address", "latlng"},
+		},
+		{
+			description:   "mixed update is split",
+			token:         ownerSession(4),
+			body:          `{"hours": "11-9", "email": "owner@bobsburgers.example.com"}`,
+			expectedCode:  http.StatusAccepted,
+			appliedFields: []string{"hours"},
+			pendingFields: []string{"email"},
+		},
+	}
+
+	fieldsOf := func(update models.RestaurantUpdate) []string {
+		fields := map[string]interface{}{}
+		encoded, _ := json.Marshal(update)
+		json.Unmarshal(encoded, &fields)
+
+		names := []string{}
+		for name := range fields {
+			names = append(names, name)
+		}
+		return names
+	}
+
+	for _, tc := range tests {
+		e := &mockEntityInterface{testRest: testRestaurant()}
+		ee := &mockEditEntity{}
+		c := NewController(mux.NewRouter(), e, &mockOwnerEntity{}, ee, mockGeocoder{}, &services.MemoryMailer{}, testSigner, services.NewRateLimiter(100, time.Hour), "https://api.example.com")
+
+		rr := serveAs(c, tc.token, "PATCH", "/owners/restaurants/4", tc.body)
+		assert.Equal(tc.expectedCode, rr.Code, tc.description)
+		if tc.expectedBody != "" {
+			assert.Equal(tc.expectedBody, rr.Body.String(), tc.description)
+		}
+
+		if len(tc.appliedFields) > 0 && assert.Len(e.updates, 1, tc.description) {
+			assert.ElementsMatch(tc.appliedFields, fieldsOf(e.updates[0]), tc.description)
+		} else {
+			assert.Empty(e.updates, tc.description)
+		}
+
+		if len(tc.pendingFields) > 0 && assert.Len(ee.edits, 1, tc.description) {
+			assert.ElementsMatch(tc.pendingFields, fieldsOf(ee.edits[0].Changes), tc.description)
+			assert.Equal(models.EditSourceOwner, ee.edits[0].Source, tc.description)
+			assert.Equal("bob@gmail.com", ee.edits[0].Actor, tc.description)
+		} else {
+			assert.Empty(ee.edits, tc.description)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package owners
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-playground/validator"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type updateResponse struct {
+	Restaurant    *models.Restaurant `json:"restaurant"`
+	PendingEditID *uint              `json:"pending_edit_id,omitempty"`
+}
+
+// update applies an owner's low-risk changes straight away and sends changes
+// to the name, address or email to the moderators.
+func (c *Controller) update(w http.ResponseWriter, r *http.Request) {
+	session := services.OwnerFromContext(r.Context())
+
+	restaurant, ok := c.loadRestaurant(w, r)
+	if !ok {
+		return
+	}
+
+	if !session.Owns(restaurant.ID) {
+		http.Error(w, "You don't manage this listing", http.StatusForbidden)
+		return
+	}
+
+	update := new(models.RestaurantUpdate)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the location always comes from geocoding the address
+	update.LatLng = nil
+
+	if update.IsEmpty() {
+		http.Error(w, "Could not update entry as it did not contain any changes", http.StatusBadRequest)
+		return
+	}
+
+	// validate the listing as it would look with every change applied
+	proposed := *restaurant
+	update.Apply(&proposed)
+	proposed.NormalizeAddress()
+
+	validate := models.NewValidator()
+	if err := validate.Struct(proposed); err != nil {
+		if _, ok := err.(*validator.InvalidValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var invalidFieldsStr strings.Builder
+
+		for _, err := range err.(validator.ValidationErrors) {
+			invalidFieldsStr.WriteString(err.Field() + ", ")
+		}
+
+		http.Error(w, fmt.Sprintf("Could not update entry as it contained missing or invalid fields: %s", invalidFieldsStr.String()), http.StatusBadRequest)
+		return
+	}
+
+	if err := proposed.NormalizePhone(); err != nil {
+		http.Error(w, "Could not update entry as it contained missing or invalid fields: Phone, ", http.StatusBadRequest)
+		return
+	}
+
+	if !proposed.ServiceModes.Any() {
+		http.Error(w, "Could not update entry as it must offer at least one service mode: takeout, curbside, delivery, drive_through", http.StatusBadRequest)
+		return
+	}
+
+	if update.Translations != nil {
+		if err := update.Translations.Validate(models.TranslatableRestaurantFields...); err != nil {
+			http.Error(w, fmt.Sprintf("Could not update entry as it contained invalid translations: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if update.ChangesAddress() {
+		point, err := c.geocoder.GeocodeAddress(
+			fmt.Sprintf("%s %s", proposed.Address, proposed.Address2),
+			proposed.City,
+			proposed.State,
+			proposed.Zipcode,
+			proposed.Country,
+		)
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem saving your changes: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+
+		if point == nil {
+			point = &models.GeoPoint{}
+		}
+
+		update.LatLng = point
+	}
+
+	lowRisk, highRisk := update.Split()
+	response := updateResponse{Restaurant: restaurant}
+	status := http.StatusOK
+
+	if !lowRisk.IsEmpty() {
+		updated, err := c.e.UpdateRestaurant(restaurant.ID, lowRisk, models.RevisionSourceOwner, session.Email)
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem saving your changes: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+
+		if updated == nil {
+			http.Error(w, "restaurant not found", http.StatusNotFound)
+			return
+		}
+
+		response.Restaurant = updated
+	}
+
+	if !highRisk.IsEmpty() {
+		editID, err := c.ee.CreateEdit(models.BusinessEdit{
+			BusinessID: restaurant.ID,
+			Source:     models.EditSourceOwner,
+			Actor:      session.Email,
+			Changes:    highRisk,
+		}, *response.Restaurant)
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem saving your changes: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+
+		response.PendingEditID = editID
+		status = http.StatusAccepted
+	}
+
+	payload, _ := json.Marshal(response)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(payload)
+	return
+}
@@ -0,0 +1,131 @@
+package photos
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	ThumbnailWidth  = 400
+	ThumbnailHeight = 300
+
+	maxPixels = 50000000
+)
+
+var (
+	errUnsupportedType = errors.New("photo must be a JPEG, PNG or WebP image")
+	errTooLarge        = errors.New("photo dimensions are too large")
+)
+
+type processedImage struct {
+	Original    []byte
+	Thumbnail   []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// processImage decodes an upload and re-encodes it from the raw pixels, which
+// drops all metadata including EXIF GPS coordinates, then builds a fixed-size
+// thumbnail cropped from the center of the image.
+func processImage(data []byte) (*processedImage, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp":
+	default:
+		return nil, errUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errUnsupportedType
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, errTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errUnsupportedType
+	}
+
+	result := &processedImage{
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	// PNGs keep their format so transparency survives, everything else is
+	// stored as JPEG since there is no WebP encoder in the standard library.
+	if contentType == "image/png" {
+		result.ContentType = "image/png"
+		result.Extension = "png"
+		result.Original, err = encodePNG(img)
+	} else {
+		result.ContentType = "image/jpeg"
+		result.Extension = "jpg"
+		result.Original, err = encodeJPEG(img)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Thumbnail, err = encodeJPEG(thumbnail(img, ThumbnailWidth, ThumbnailHeight))
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func thumbnail(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	crop := bounds
+
+	// crop to the thumbnail aspect ratio before scaling
+	if bounds.Dx()*height > bounds.Dy()*width {
+		cropWidth := bounds.Dy() * width / height
+		crop.Min.X = bounds.Min.X + (bounds.Dx()-cropWidth)/2
+		crop.Max.X = crop.Min.X + cropWidth
+	} else {
+		cropHeight := bounds.Dx() * height / width
+		crop.Min.Y = bounds.Min.Y + (bounds.Dy()-cropHeight)/2
+		crop.Max.Y = crop.Min.Y + cropHeight
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+
+	return dst
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	// JPEG has no alpha channel, so flatten transparent areas onto white
+	flattened := image.NewRGBA(img.Bounds())
+	draw.Draw(flattened, flattened.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flattened, flattened.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flattened, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
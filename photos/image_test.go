@@ -0,0 +1,68 @@
+package photos
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildJPEGWithExif(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// splice an APP1 segment carrying fake GPS data in after the SOI marker
+	exif := append([]byte("Exif\x00\x00"), []byte("GPSLatitude=43.5446;GPSLongitude=-96.7311")...)
+	segment := []byte{0xFF, 0xE1, byte((len(exif) + 2) >> 8), byte(len(exif) + 2)}
+	segment = append(segment, exif...)
+
+	data := buf.Bytes()
+	withExif := append([]byte{}, data[:2]...)
+	withExif = append(withExif, segment...)
+	withExif = append(withExif, data[2:]...)
+
+	return withExif
+}
+
+func TestProcessImage(t *testing.T) {
+	assert := assert.New(t)
+
+	upload := buildJPEGWithExif(t, 800, 400)
+	assert.True(bytes.Contains(upload, []byte("GPSLatitude")))
+
+	processed, err := processImage(upload)
+	assert.NoError(err)
+
+	assert.Equal("image/jpeg", processed.ContentType)
+	assert.Equal(800, processed.Width)
+	assert.Equal(400, processed.Height)
+	assert.False(bytes.Contains(processed.Original, []byte("Exif")), "original should not keep EXIF data")
+	assert.False(bytes.Contains(processed.Original, []byte("GPSLatitude")), "original should not keep GPS data")
+
+	thumb, err := jpeg.Decode(bytes.NewReader(processed.Thumbnail))
+	assert.NoError(err)
+	assert.Equal(ThumbnailWidth, thumb.Bounds().Dx())
+	assert.Equal(ThumbnailHeight, thumb.Bounds().Dy())
+}
+
+func TestProcessImageRejectsUnsupportedTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := processImage([]byte("GIF89a this is not a supported photo"))
+	assert.Equal(errUnsupportedType, err)
+
+	_, err = processImage([]byte("<html><body>not an image</body></html>"))
+	assert.Equal(errUnsupportedType, err)
+}
@@ -0,0 +1,116 @@
+package photos
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type mockPhotoEntity struct {
+	services.PhotoEntityInterface
+	photos []models.Photo
+}
+
+func (e mockPhotoEntity) GetPhotoByKey(key string) (*models.Photo, error) {
+	for _, photo := range e.photos {
+		if photo.StorageKey == key || photo.ThumbnailKey == key {
+			return &photo, nil
+		}
+	}
+
+	return nil, nil
+}
+
+var testSigner = services.TokenSigner{Secret: []byte("media-test-secret")}
+
+func buildMediaController(t *testing.T) (Controller, func()) {
+	dir, err := ioutil.TempDir("", "media")
+	assert.NoError(t, err)
+
+	storage := services.LocalStorage{Dir: dir}
+	for _, key := range []string{"photos/4/approved.jpg", "photos/4/approved_thumb.jpg", "photos/4/pending.jpg"} {
+		assert.NoError(t, storage.Put(key, strings.NewReader("jpeg")))
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("TOKEN_SECRET=x"), 0600))
+
+	approved := models.Photo{BusinessID: 4, StorageKey: "photos/4/approved.jpg", ThumbnailKey: "photos/4/approved_thumb.jpg", ContentType: "image/jpeg", IsApproved: true}
+	approved.ID = 1
+	pending := models.Photo{BusinessID: 4, StorageKey: "photos/4/pending.jpg", ThumbnailKey: "photos/4/pending_thumb.jpg", ContentType: "image/jpeg"}
+	pending.ID = 2
+
+	pe := mockPhotoEntity{photos: []models.Photo{approved, pending}}
+	c := NewController(mux.NewRouter(), nil, pe, storage, testSigner)
+
+	return c, func() { os.RemoveAll(dir) }
+}
+
+func getMedia(c Controller, target string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", target, nil)
+	rr := httptest.NewRecorder()
+	c.r.ServeHTTP(rr, req)
+	return rr
+}
+
+func previewToken(photoID uint) string {
+	token, _ := testSigner.Sign(services.TokenClaims{
+		Purpose:   services.TokenPurposePhotoPreview,
+		PhotoID:   photoID,
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+
+	return token
+}
+
+func TestMediaServesApprovedPhotos(t *testing.T) {
+	assert := assert.New(t)
+
+	c, cleanup := buildMediaController(t)
+	defer cleanup()
+
+	rr := getMedia(c, "/media/photos/4/approved.jpg")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("jpeg", rr.Body.String())
+
+	rr = getMedia(c, "/media/photos/4/approved_thumb.jpg")
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("image/jpeg", rr.Header().Get("Content-Type"))
+}
+
+func TestMediaHidesPendingPhotos(t *testing.T) {
+	assert := assert.New(t)
+
+	c, cleanup := buildMediaController(t)
+	defer cleanup()
+
+	rr := getMedia(c, "/media/photos/4/pending.jpg")
+	assert.Equal(http.StatusNotFound, rr.Code)
+
+	rr = getMedia(c, "/media/photos/4/pending.jpg?preview="+previewToken(1))
+	assert.Equal(http.StatusNotFound, rr.Code)
+
+	rr = getMedia(c, "/media/photos/4/pending.jpg?preview="+previewToken(2))
+	assert.Equal(http.StatusOK, rr.Code)
+}
+
+func TestMediaOnlyServesKnownPhotos(t *testing.T) {
+	assert := assert.New(t)
+
+	c, cleanup := buildMediaController(t)
+	defer cleanup()
+
+	for _, target := range []string{"/media/.env", "/media/photos/4/", "/media/photos/4", "/media/../server.go"} {
+		rr := getMedia(c, target)
+		assert.NotEqual(http.StatusOK, rr.Code, target)
+		assert.NotContains(rr.Body.String(), "TOKEN_SECRET", target)
+	}
+}
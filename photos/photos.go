@@ -0,0 +1,227 @@
+package photos
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+const (
+	MaxPhotoSize   = 10 << 20
+	MaxPhotoUpload = 5
+)
+
+type Controller struct {
+	r       *mux.Router
+	e       services.RestaurantEntityInterface
+	pe      services.PhotoEntityInterface
+	storage services.BlobStorage
+	signer  services.TokenSigner
+}
+
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, pei services.PhotoEntityInterface, storage services.BlobStorage, signer services.TokenSigner) Controller {
+	c := Controller{
+		r:       router,
+		e:       ei,
+		pe:      pei,
+		storage: storage,
+		signer:  signer,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/restaurants").Subrouter()
+	s.HandleFunc("/{id}/photos", c.upload).Methods("POST")
+
+	c.r.HandleFunc("/media/{key:.+}", c.media).Methods("GET")
+}
+
+// media serves approved photos. Pending photos need the preview token from
+// their Slack message.
+func (c *Controller) media(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	photo, err := c.pe.GetPhotoByKey(key)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem loading the photo: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if photo == nil || (!photo.IsApproved && !c.canPreview(r, *photo)) {
+		http.NotFound(w, r)
+		return
+	}
+
+	blob, err := c.storage.Open(key)
+	if os.IsNotExist(err) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem loading the photo: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	contentType := photo.ContentType
+	if key == photo.ThumbnailKey {
+		contentType = "image/jpeg"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	http.ServeContent(w, r, path.Base(key), photo.UpdatedAt, blob)
+}
+
+func (c *Controller) canPreview(r *http.Request, photo models.Photo) bool {
+	claims, err := c.signer.Verify(r.URL.Query().Get("preview"), services.TokenPurposePhotoPreview)
+	return err == nil && claims.PhotoID == photo.ID
+}
+
+func (c *Controller) upload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	restID, err := strconv.ParseUint(strings.TrimSpace(vars["id"]), 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your photo: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil || !restaurant.IsPublic() {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxPhotoUpload*MaxPhotoSize)
+	if err := r.ParseMultipartForm(MaxPhotoSize); err != nil {
+		http.Error(w, fmt.Sprintf("Could not read upload: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["photo"]
+	if len(files) == 0 {
+		http.Error(w, "Upload must include at least one photo", http.StatusBadRequest)
+		return
+	}
+
+	if len(files) > MaxPhotoUpload {
+		http.Error(w, fmt.Sprintf("Upload can include at most %d photos", MaxPhotoUpload), http.StatusBadRequest)
+		return
+	}
+
+	photos := []models.Photo{}
+
+	for _, header := range files {
+		if header.Size > MaxPhotoSize {
+			http.Error(w, fmt.Sprintf("%s is larger than %d MB", header.Filename, MaxPhotoSize>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		processed, err := processImage(data)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", header.Filename, err), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		photo, err := c.store(uint(restID), processed)
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem saving your photo: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+
+		photos = append(photos, *photo)
+	}
+
+	payload, _ := json.Marshal(photos)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(payload)
+	return
+}
+
+func (c *Controller) store(restID uint, processed *processedImage) (*models.Photo, error) {
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+
+	photo := models.Photo{
+		BusinessID:   restID,
+		StorageKey:   fmt.Sprintf("photos/%d/%s.%s", restID, name, processed.Extension),
+		ThumbnailKey: fmt.Sprintf("photos/%d/%s_thumb.jpg", restID, name),
+		ContentType:  processed.ContentType,
+		Width:        processed.Width,
+		Height:       processed.Height,
+	}
+	photo.URL = c.storage.URL(photo.StorageKey)
+	photo.ThumbnailURL = c.storage.URL(photo.ThumbnailKey)
+
+	if err := c.storage.Put(photo.StorageKey, bytes.NewReader(processed.Original)); err != nil {
+		return nil, err
+	}
+
+	if err := c.storage.Put(photo.ThumbnailKey, bytes.NewReader(processed.Thumbnail)); err != nil {
+		c.storage.Delete(photo.StorageKey)
+		return nil, err
+	}
+
+	photoID, err := c.pe.CreatePhoto(photo)
+	if err != nil {
+		c.storage.Delete(photo.StorageKey)
+		c.storage.Delete(photo.ThumbnailKey)
+		return nil, err
+	}
+
+	photo.ID = *photoID
+
+	return &photo, nil
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,120 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+// Each address can file a handful of reports an hour.
+const (
+	ReportLimit       = 5
+	ReportLimitWindow = time.Hour
+)
+
+type Controller struct {
+	r       *mux.Router
+	e       services.RestaurantEntityInterface
+	rpe     services.ReportEntityInterface
+	limiter *services.RateLimiter
+	hasher  services.ClientHasher
+}
+
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, rpei services.ReportEntityInterface, limiter *services.RateLimiter, hasher services.ClientHasher) Controller {
+	c := Controller{
+		r:       router,
+		e:       ei,
+		rpe:     rpei,
+		limiter: limiter,
+		hasher:  hasher,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/restaurants").Subrouter()
+	s.HandleFunc("/{id}/reports", c.create).Methods("POST")
+}
+
+func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	restID, err := strconv.ParseUint(strings.TrimSpace(vars["id"]), 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	newReport := new(models.Report)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&newReport); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Data validation
+	validate := models.NewValidator()
+	if err := validate.Struct(newReport); err != nil {
+		if _, ok := err.(*validator.InvalidValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var invalidFieldsStr strings.Builder
+
+		for _, err := range err.(validator.ValidationErrors) {
+			invalidFieldsStr.WriteString(err.Field() + ", ")
+		}
+
+		http.Error(w, fmt.Sprintf("Could not create report as it contained missing or invalid fields: %s", invalidFieldsStr.String()), http.StatusBadRequest)
+		return
+	}
+
+	clientIP := services.ClientIP(r)
+	if !c.limiter.Allow(clientIP) {
+		http.Error(w, "Too many reports, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your report: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil || !restaurant.IsPublic() {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	newReport.BusinessID = restaurant.ID
+	newReport.ReporterHash = c.hasher.Hash(clientIP)
+
+	report, err := c.rpe.CreateReport(*newReport, *restaurant)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your report: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	payload, _ := json.Marshal(report)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(payload)
+	return
+}
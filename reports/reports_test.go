@@ -0,0 +1,358 @@
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type handlerTests struct {
+	description        string
+	url                string
+	method             string
+	remoteAddr         string
+	body               io.Reader
+	expectedStatusCode int
+	expectedBody       string
+	entityClient       services.RestaurantEntityInterface
+	reportClient       services.ReportEntityInterface
+	limiter            *services.RateLimiter
+}
+
+var testHasher = services.ClientHasher{Secret: []byte("test-secret")}
+
+func buildController(ei services.RestaurantEntityInterface, rpei services.ReportEntityInterface, limiter *services.RateLimiter) Controller {
+	if limiter == nil {
+		limiter = services.NewRateLimiter(ReportLimit, ReportLimitWindow)
+	}
+
+	controller := NewController(mux.NewRouter(), ei, rpei, limiter, testHasher)
+	return controller
+}
+
+func runTestCases(t *testing.T, testcases []handlerTests) {
+	assert := assert.New(t)
+
+	for _, testcase := range testcases {
+		c := buildController(testcase.entityClient, testcase.reportClient, testcase.limiter)
+
+		req, err := http.NewRequest(testcase.method, testcase.url, testcase.body)
+		assert.NoError(err)
+		req.RemoteAddr = testcase.remoteAddr
+
+		rr := httptest.NewRecorder()
+		c.r.ServeHTTP(rr, req)
+
+		assert.Equal(testcase.expectedStatusCode, rr.Code, testcase.description)
+		assert.Equal(testcase.expectedBody, rr.Body.String(), testcase.description)
+	}
+}
+
+type mockTestMode int
+
+const (
+	Success mockTestMode = iota
+	Fail
+	Error
+	NotFound
+)
+
+type mockEntityInterface struct {
+	mode     mockTestMode
+	testRest *models.Restaurant
+}
+
+func (e mockEntityInterface) CreateRestaurant(newRestaurant models.Restaurant) (*uint, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetRestaurants(lat *float64, lng *float64, filters models.RestaurantFilters) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetRestaurant(id uint) (*models.Restaurant, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, nil
+	case Fail:
+		return nil, errors.New("could not find restaurant by id")
+	case NotFound:
+		return nil, nil
+	}
+
+	return nil, nil
+}
+
+func (e mockEntityInterface) MergeRestaurants(targetID uint, sourceID uint, choices models.MergeChoices, actor string) (*models.Restaurant, map[uint]uint, error) {
+	return nil, nil, nil
+}
+
+func (e mockEntityInterface) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) SearchRestaurants(term string, limit int) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetModerationStats() (*models.ModerationStats, error) {
+	return nil, nil
+}
+
+type mockReportEntity struct {
+	mode mockTestMode
+}
+
+// CreateReport echoes the report back, failing unless the reporter was hashed
+// from the client address without its port.
+func (e mockReportEntity) CreateReport(newReport models.Report, restaurant models.Restaurant) (*models.Report, error) {
+	switch e.mode {
+	case Success:
+		if newReport.ReporterHash != testHasher.Hash("203.0.113.9") && newReport.ReporterHash != testHasher.Hash("198.51.100.7") {
+			return nil, errors.New("unexpected reporter hash")
+		}
+
+		newReport.ID = 1
+		newReport.Status = models.ReportPending
+		return &newReport, nil
+	case Fail:
+		return nil, errors.New("could not write report to db")
+	}
+
+	return nil, nil
+}
+
+func (e mockReportEntity) HideReported(reportID uint, reviewer string) (*models.Report, error) {
+	return nil, nil
+}
+
+func (e mockReportEntity) DismissReport(reportID uint, reviewer string) (*models.Report, error) {
+	return nil, nil
+}
+
+func testRestaurant() *models.Restaurant {
+	restaurant := &models.Restaurant{Name: "Bob's Burgers", IsActive: true}
+	restaurant.ID = 4
+
+	return restaurant
+}
+
+// reportJSON is what the handler returns for a report the mock saved.
+func reportJSON(reason string, note string) string {
+	report := models.Report{BusinessID: 4, Reason: reason, Note: note, Status: models.ReportPending}
+	report.ID = 1
+
+	payload, _ := json.Marshal(report)
+	return string(payload)
+}
+
+func TestCreateHandler(t *testing.T) {
+	rejectedAt := time.Now()
+	rejected := testRestaurant()
+	rejected.RejectedAt = &rejectedAt
+
+	pending := testRestaurant()
+	pending.IsActive = false
+
+	expectedReport := reportJSON(models.ReportClosed, "Sign on the door says closed for good")
+
+	tests := []handlerTests{
+		{
+			description:        "successful report",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed", "note": "Sign on the door says closed for good"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       expectedReport,
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "unknown reason",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "rude staff"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create report as it contained missing or invalid fields: Reason, \n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "missing reason",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"note": "closed"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create report as it contained missing or invalid fields: Reason, \n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "unknown field",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed", "email": "a@example.com"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "json: unknown field \"email\"\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "note too long",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "spam", "note": "` + strings.Repeat("a", 501) + `"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create report as it contained missing or invalid fields: Note, \n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "invalid restaurant id",
+			url:                "/restaurants/abc/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed"}`),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "unknown restaurant",
+			url:                "/restaurants/9/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed"}`),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: NotFound},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "rejected restaurant",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed"}`),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: rejected},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "pending restaurant",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed"}`),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: pending},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "failed restaurant lookup",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed"}`),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your report: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Fail},
+			reportClient:       mockReportEntity{mode: Success},
+		}, {
+			description:        "failed report creation",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "closed"}`),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your report: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			reportClient:       mockReportEntity{mode: Fail},
+		},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCreateHandlerRateLimit(t *testing.T) {
+	entity := mockEntityInterface{mode: Success, testRest: testRestaurant()}
+	reports := mockReportEntity{mode: Success}
+	limiter := services.NewRateLimiter(2, time.Hour)
+
+	expectedReport := reportJSON(models.ReportWrongHours, "")
+
+	tests := []handlerTests{
+		{
+			description:        "first report",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "wrong_hours"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       expectedReport,
+			entityClient:       entity,
+			reportClient:       reports,
+			limiter:            limiter,
+		}, {
+			description:        "second report",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:5123",
+			body:               strings.NewReader(`{"reason": "wrong_hours"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       expectedReport,
+			entityClient:       entity,
+			reportClient:       reports,
+			limiter:            limiter,
+		}, {
+			description:        "same address on another port over the limit",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "203.0.113.9:6000",
+			body:               strings.NewReader(`{"reason": "wrong_hours"}`),
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedBody:       "Too many reports, please try again later\n",
+			entityClient:       entity,
+			reportClient:       reports,
+			limiter:            limiter,
+		}, {
+			description:        "another address",
+			url:                "/restaurants/4/reports",
+			method:             "POST",
+			remoteAddr:         "198.51.100.7:6000",
+			body:               strings.NewReader(`{"reason": "wrong_hours"}`),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       expectedReport,
+			entityClient:       entity,
+			reportClient:       reports,
+			limiter:            limiter,
+		},
+	}
+
+	runTestCases(t, tests)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/go-playground/validator"
@@ -14,17 +15,32 @@ import (
 	"github.com/theproducer/openfortakeout_api/services"
 )
 
+// Submissions email the address they contain, so each client address can
+// only create a few an hour.
+const (
+	CreateLimit       = 10
+	CreateLimitWindow = time.Hour
+)
+
 type Controller struct {
 	r        *mux.Router
 	e        services.RestaurantEntityInterface
-	geocoder services.GeocodioService
+	ae       services.AttributeEntityInterface
+	tre      services.TranslationEntityInterface
+	geocoder services.GeocodioServiceInterface
+	notifier services.SubmissionNotifier
+	limiter  *services.RateLimiter
 }
 
-func NewController(router *mux.Router, ei services.RestaurantEntityInterface, g services.GeocodioService) Controller {
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, aei services.AttributeEntityInterface, trei services.TranslationEntityInterface, g services.GeocodioServiceInterface, notifier services.SubmissionNotifier, limiter *services.RateLimiter) Controller {
 	c := Controller{
 		r:        router,
 		e:        ei,
+		ae:       aei,
+		tre:      trei,
 		geocoder: g,
+		notifier: notifier,
+		limiter:  limiter,
 	}
 
 	c.routes()
@@ -57,27 +73,108 @@ func (c *Controller) list(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if lat == 0.00 && lng == 0.00 && zipcode != "" {
+		country := models.NormalizeCountry(r.URL.Query().Get("country"))
+		if !models.IsCountryCode(country) {
+			http.Error(w, fmt.Sprintf("Unknown country: %s", country), http.StatusBadRequest)
+			return
+		}
+
 		// convert zipcode to lat/lng
-		point, err := c.geocoder.GeocodeZipcode(zipcode)
+		point, err := c.geocoder.GeocodeZipcode(country, models.NormalizePostalCode(country, zipcode))
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem generating results: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+
+		if point != nil {
+			lat = point.Lat
+			lng = point.Lng
+		}
+	}
+
+	filters := models.RestaurantFilters{}
+
+	if channelStr := r.URL.Query().Get("channel"); channelStr != "" {
+		for _, channel := range strings.Split(channelStr, ",") {
+			channel = strings.TrimSpace(channel)
+			if !models.IsOrderingChannel(channel) {
+				http.Error(w, fmt.Sprintf("Unknown ordering channel: %s", channel), http.StatusBadRequest)
+				return
+			}
+			filters.Channels = append(filters.Channels, channel)
+		}
+	}
+
+	if serviceStr := r.URL.Query().Get("service"); serviceStr != "" {
+		for _, mode := range strings.Split(serviceStr, ",") {
+			mode = strings.TrimSpace(mode)
+			if !models.IsServiceMode(mode) {
+				http.Error(w, fmt.Sprintf("Unknown service mode: %s", mode), http.StatusBadRequest)
+				return
+			}
+			filters.ServiceModes = append(filters.ServiceModes, mode)
+		}
+	}
+
+	if paymentStr := r.URL.Query().Get("payment"); paymentStr != "" {
+		for _, method := range strings.Split(paymentStr, ",") {
+			method = strings.TrimSpace(method)
+			if !models.IsPaymentMethod(method) {
+				http.Error(w, fmt.Sprintf("Unknown payment method: %s", method), http.StatusBadRequest)
+				return
+			}
+			filters.PaymentMethods = append(filters.PaymentMethods, method)
+		}
+	}
+
+	if attributeStr := r.URL.Query().Get("attribute"); attributeStr != "" {
+		attributes := strings.Split(attributeStr, ",")
+		unknown, err := c.unknownAttributes(attributes)
 		if err != nil {
 			eventID := sentry.CaptureException(err)
 			http.Error(w, fmt.Sprintf("There was a problem generating results: ID: %v", eventID), http.StatusInternalServerError)
 			return
 		}
 
-		lat = point.Lat
-		lng = point.Lng
+		if len(unknown) > 0 {
+			http.Error(w, fmt.Sprintf("Unknown attribute: %s", strings.Join(unknown, ", ")), http.StatusBadRequest)
+			return
+		}
+
+		filters.Attributes = attributes
 	}
 
-	restaurants, err := c.e.GetRestaurants(&lat, &lng)
+	filters.OpenNow, _ = strconv.ParseBool(r.URL.Query().Get("open_now"))
+
+	switch sortStr := r.URL.Query().Get("sort"); sortStr {
+	case "", "name":
+	case "freshness":
+		filters.SortByFreshness = true
+	default:
+		http.Error(w, fmt.Sprintf("Unknown sort order: %s", sortStr), http.StatusBadRequest)
+		return
+	}
+
+	restaurants, err := c.e.GetRestaurants(&lat, &lng, filters)
 	if err != nil {
 		eventID := sentry.CaptureException(err)
 		http.Error(w, fmt.Sprintf("There was a problem generating results: ID: %v", eventID), http.StatusInternalServerError)
 		return
 	}
 
+	locale := models.MatchLocale(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	if err := c.tre.LocalizeRestaurants(*restaurants, locale); err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem generating results: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
 	payload, _ := json.Marshal(restaurants)
 
+	w.Header().Set("Content-Language", locale)
+	w.Header().Set("Vary", "Accept-Language")
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(payload)
@@ -101,8 +198,28 @@ func (c *Controller) get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	payload, _ := json.Marshal(restaurant)
+	if restaurant == nil || restaurant.RejectedAt != nil || (restaurant.DeletedAt != nil && restaurant.MergedInto == nil) {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	// merged listings permanently redirect to the listing that replaced them
+	if restaurant.DeletedAt != nil && restaurant.MergedInto != nil {
+		http.Redirect(w, r, fmt.Sprintf("/restaurants/%d", *restaurant.MergedInto), http.StatusMovedPermanently)
+		return
+	}
+
+	locale := models.MatchLocale(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	localized := []models.Restaurant{*restaurant}
+	if err := c.tre.LocalizeRestaurants(localized, locale); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	payload, _ := json.Marshal(localized[0])
 
+	w.Header().Set("Content-Language", locale)
+	w.Header().Set("Vary", "Accept-Language")
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(payload)
@@ -110,6 +227,11 @@ func (c *Controller) get(w http.ResponseWriter, r *http.Request) {
 }
 
 func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	if !c.limiter.Allow(services.ClientIP(r)) {
+		http.Error(w, "Too many submissions, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
 	newRest := new(models.Restaurant)
 
 	decoder := json.NewDecoder(r.Body)
@@ -119,8 +241,10 @@ func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	newRest.NormalizeAddress()
+
 	// Data validation
-	validate := validator.New()
+	validate := models.NewValidator()
 	if err := validate.Struct(newRest); err != nil {
 		if _, ok := err.(*validator.InvalidValidationError); ok {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -137,12 +261,40 @@ func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := newRest.NormalizePhone(); err != nil {
+		http.Error(w, "Could not create entry as it contained missing or invalid fields: Phone, ", http.StatusBadRequest)
+		return
+	}
+
+	if !newRest.ServiceModes.Any() {
+		http.Error(w, "Could not create entry as it must offer at least one service mode: takeout, curbside, delivery, drive_through", http.StatusBadRequest)
+		return
+	}
+
+	if err := newRest.Translations.Validate(models.TranslatableRestaurantFields...); err != nil {
+		http.Error(w, fmt.Sprintf("Could not create entry as it contained invalid translations: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	unknown, err := c.unknownAttributes(newRest.Attributes)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a saving your entry: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if len(unknown) > 0 {
+		http.Error(w, fmt.Sprintf("Could not create entry as it contained unknown attributes: %s", strings.Join(unknown, ", ")), http.StatusBadRequest)
+		return
+	}
+
 	// Geocode address
 	point, err := c.geocoder.GeocodeAddress(
 		fmt.Sprintf("%s %s", newRest.Address, newRest.Address2),
 		newRest.City,
 		newRest.State,
 		newRest.Zipcode,
+		newRest.Country,
 	)
 	if err != nil {
 		eventID := sentry.CaptureException(err)
@@ -163,6 +315,11 @@ func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// a failed notification shouldn't fail the submission
+	if err := c.notifier.SubmissionReceived(*newRest); err != nil {
+		sentry.CaptureException(err)
+	}
+
 	restIDStr := strconv.Itoa(int(*restID))
 
 	w.Header().Set("Content-Type", "application/json")
@@ -170,3 +327,31 @@ func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(restIDStr + "\n"))
 	return
 }
+
+// unknownAttributes normalizes attribute slugs in place and returns the ones
+// that aren't defined.
+func (c *Controller) unknownAttributes(slugs []string) ([]string, error) {
+	if len(slugs) == 0 {
+		return nil, nil
+	}
+
+	attributes, err := c.ae.GetAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	defined := map[string]bool{}
+	for _, attribute := range *attributes {
+		defined[attribute.Slug] = true
+	}
+
+	unknown := []string{}
+	for i, slug := range slugs {
+		slugs[i] = strings.ToLower(strings.TrimSpace(slug))
+		if !defined[slugs[i]] {
+			unknown = append(unknown, slugs[i])
+		}
+	}
+
+	return unknown, nil
+}
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -27,7 +28,7 @@ type handlerTests struct {
 }
 
 func buildController(ei services.RestaurantEntityInterface) Controller {
-	controller := NewController(mux.NewRouter(), ei)
+	controller := NewController(mux.NewRouter(), ei, mockAttributeEntity{}, mockTranslationEntity{}, mockGeocoder{}, services.SubmissionNotifier{}, services.NewRateLimiter(CreateLimit, CreateLimitWindow))
 	return controller
 }
 
@@ -75,7 +76,45 @@ func (e mockEntityInterface) CreateRestaurant(newRestaurant models.Restaurant) (
 	return nil, nil
 }
 
-func (e mockEntityInterface) GetRestaurants(lat *float64, lng *float64) (*[]models.Restaurant, error) {
+func (e mockEntityInterface) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, true, nil
+	case Fail:
+		return nil, false, errors.New("could not approve restaurant")
+	}
+
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, true, nil
+	case Fail:
+		return nil, false, errors.New("could not reject restaurant")
+	}
+
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	return e.testRests, nil
+}
+
+func (e mockEntityInterface) SearchRestaurants(term string, limit int) (*[]models.Restaurant, error) {
+	return e.testRests, nil
+}
+
+func (e mockEntityInterface) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	return e.testRest, true, nil
+}
+
+func (e mockEntityInterface) GetModerationStats() (*models.ModerationStats, error) {
+	return &models.ModerationStats{}, nil
+}
+
+func (e mockEntityInterface) GetRestaurants(lat *float64, lng *float64, filters models.RestaurantFilters) (*[]models.Restaurant, error) {
 	switch e.mode {
 	case Success:
 		return e.testRests, nil
@@ -102,6 +141,64 @@ func (e mockEntityInterface) GetRestaurant(id uint) (*models.Restaurant, error)
 	return nil, nil
 }
 
+func (e mockEntityInterface) MergeRestaurants(targetID uint, sourceID uint, choices models.MergeChoices, actor string) (*models.Restaurant, map[uint]uint, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, map[uint]uint{sourceID: targetID}, nil
+	case Fail:
+		return nil, nil, errors.New("could not merge restaurants")
+	}
+
+	return nil, nil, nil
+}
+
+func (e mockEntityInterface) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, nil
+	case Fail:
+		return nil, errors.New("could not update restaurant")
+	}
+
+	return nil, nil
+}
+
+type mockAttributeEntity struct{}
+
+func (e mockAttributeEntity) GetAttributes() (*[]models.Attribute, error) {
+	return &[]models.Attribute{
+		{Slug: "vegan", Name: "Vegan", Category: models.AttributeDietary},
+		{Slug: "halal", Name: "Halal", Category: models.AttributeDietary},
+		{Slug: "wheelchair_accessible_pickup", Name: "Wheelchair-accessible pickup", Category: models.AttributeAccessibility},
+	}, nil
+}
+
+// mockTranslationEntity prefixes details with the locale for anything other
+// than the default.
+type mockTranslationEntity struct{}
+
+func (e mockTranslationEntity) LocalizeRestaurants(restaurants []models.Restaurant, locale string) error {
+	if locale == models.DefaultLocale {
+		return nil
+	}
+
+	for i := range restaurants {
+		restaurants[i].Localize(map[string]string{"details": locale + ": " + restaurants[i].Details})
+	}
+
+	return nil
+}
+
+type mockGeocoder struct{}
+
+func (g mockGeocoder) GeocodeAddress(street, city, state, zipcode, country string) (*models.GeoPoint, error) {
+	return &models.GeoPoint{Lat: 43.5, Lng: -96.7}, nil
+}
+
+func (g mockGeocoder) GeocodeZipcode(country, zipcode string) (*models.GeoPoint, error) {
+	return &models.GeoPoint{Lat: 43.5, Lng: -96.7}, nil
+}
+
 func TestListHandler(t *testing.T) {
 	retSlice := []models.Restaurant{}
 	for i := 0; i < 10; i++ {
@@ -128,11 +225,110 @@ func TestListHandler(t *testing.T) {
 			method:             "GET",
 			body:               nil,
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedBody:       "could not get restaurants from db\n",
+			expectedBody:       "There was a problem generating results: ID: <nil>\n",
 			entityClient: mockEntityInterface{
 				mode:      Fail,
 				testRests: &retSlice,
 			},
+		}, {
+			description:        "filter by ordering channel",
+			url:                "/restaurants/?channel=direct,doordash",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedListReturn),
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "filter by service mode",
+			url:                "/restaurants/?service=curbside,drive_through",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedListReturn),
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "filter by attribute",
+			url:                "/restaurants/?attribute=Vegan,wheelchair_accessible_pickup",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedListReturn),
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "filter by payment method",
+			url:                "/restaurants/?payment=contactless,online_prepay",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedListReturn),
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "unknown payment method",
+			url:                "/restaurants/?payment=bitcoin",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Unknown payment method: bitcoin\n",
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "sort by freshness",
+			url:                "/restaurants/?sort=freshness",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedListReturn),
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "unknown sort order",
+			url:                "/restaurants/?sort=rating",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Unknown sort order: rating\n",
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "unknown attribute",
+			url:                "/restaurants/?attribute=vegan,paleo",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Unknown attribute: paleo\n",
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
+		}, {
+			description:        "unknown ordering channel",
+			url:                "/restaurants/?channel=carrier-pigeon",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Unknown ordering channel: carrier-pigeon\n",
+			entityClient: mockEntityInterface{
+				mode:      Success,
+				testRests: &retSlice,
+			},
 		},
 	}
 
@@ -142,6 +338,9 @@ func TestListHandler(t *testing.T) {
 func TestCreateHandler(t *testing.T) {
 	validCreateJSON := `{
 		"name": "Bob's Burgers",
+		"type": "Restaurant",
+		"phone": "605-555-0100",
+		"email": "bob@example.com",
 		"details": "Testing, 1, 2, 2, 3",
 		"hours": "10AM - 9PM",
 		"url": "http://www.apple.com",
@@ -150,7 +349,117 @@ func TestCreateHandler(t *testing.T) {
 		"city": "Sioux Falls",
 		"state": "SD",
 		"zipcode": "57106",
-		"donate_url": "http://duckduckgo.com"
+		"donate_url": "http://duckduckgo.com",
+		"service_modes": {"takeout": true, "curbside": true},
+		"ordering_channels": [
+			{"type": "doordash", "url": "https://www.doordash.com/store/bobs-burgers"},
+			{"type": "direct", "url": "https://order.bobsburgers.com"}
+		]
+	}`
+
+	invalidChannelJSON := `{
+		"name": "Bob's Burgers",
+		"type": "Restaurant",
+		"phone": "605-555-0100",
+		"email": "bob@example.com",
+		"address": "123 Main Street",
+		"city": "Sioux Falls",
+		"state": "SD",
+		"zipcode": "57106",
+		"service_modes": {"takeout": true},
+		"ordering_channels": [
+			{"type": "direct", "url": "not a url"}
+		]
+	}`
+
+	noServiceModeJSON := `{
+		"name": "Bob's Burgers",
+		"type": "Restaurant",
+		"phone": "605-555-0100",
+		"email": "bob@example.com",
+		"address": "123 Main Street",
+		"city": "Sioux Falls",
+		"state": "SD",
+		"zipcode": "57106"
+	}`
+
+	canadianCreateJSON := `{
+		"name": "Poutine Palace",
+		"type": "Restaurant",
+		"phone": "613-555-0199",
+		"email": "info@poutine.example.ca",
+		"address": "100 Wellington St",
+		"city": "Ottawa",
+		"state": "on",
+		"zipcode": "k1a0a9",
+		"country": "CA",
+		"service_modes": {"takeout": true}
+	}`
+
+	invalidPostcodeJSON := `{
+		"name": "The Chippy",
+		"type": "Restaurant",
+		"phone": "020 7946 0000",
+		"email": "hello@chippy.example.co.uk",
+		"address": "1 High Street",
+		"city": "London",
+		"zipcode": "90210",
+		"country": "GB",
+		"service_modes": {"takeout": true}
+	}`
+
+	attributesCreateJSON := `{
+		"name": "Green Bowl",
+		"type": "Restaurant",
+		"phone": "605-555-0101",
+		"email": "hi@greenbowl.example.com",
+		"address": "200 Main Street",
+		"city": "Sioux Falls",
+		"state": "SD",
+		"zipcode": "57106",
+		"service_modes": {"takeout": true},
+		"attributes": ["vegan", "halal"],
+		"payment_methods": ["contactless", "snap_ebt"],
+		"translations": {"es": {"details": "Comida vegana", "hours": "11 a 21"}, "vi": {"hours": "11 giờ đến 21 giờ"}}
+	}`
+
+	invalidTranslationJSON := `{
+		"name": "Green Bowl",
+		"type": "Restaurant",
+		"phone": "605-555-0101",
+		"email": "hi@greenbowl.example.com",
+		"address": "200 Main Street",
+		"city": "Sioux Falls",
+		"state": "SD",
+		"zipcode": "57106",
+		"service_modes": {"takeout": true},
+		"translations": {"es": {"name": "Tazón Verde"}}
+	}`
+
+	invalidPaymentJSON := `{
+		"name": "Green Bowl",
+		"type": "Restaurant",
+		"phone": "605-555-0101",
+		"email": "hi@greenbowl.example.com",
+		"address": "200 Main Street",
+		"city": "Sioux Falls",
+		"state": "SD",
+		"zipcode": "57106",
+		"service_modes": {"takeout": true},
+		"payment_methods": ["cash", "bitcoin"]
+	}`
+
+	unknownAttributeJSON := `{
+		"name": "Green Bowl",
+		"type": "Restaurant",
+		"phone": "605-555-0101",
+		"email": "hi@greenbowl.example.com",
+		"address": "200 Main Street",
+		"city": "Sioux Falls",
+		"state": "SD",
+		"zipcode": "57106",
+		"service_modes": {"takeout": true},
+		"attributes": ["vegan", "raw", "keto"]
 	}`
 
 	invalidCreateJSON := `{
@@ -178,13 +487,93 @@ func TestCreateHandler(t *testing.T) {
 			entityClient: mockEntityInterface{
 				mode: Success,
 			},
+		}, {
+			description:        "invalid ordering channel url",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(invalidChannelJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create entry as it contained missing or invalid fields: URL, \n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
+		}, {
+			description:        "canadian listing",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(canadianCreateJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       "1\n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
+		}, {
+			description:        "invalid uk postcode",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(invalidPostcodeJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create entry as it contained missing or invalid fields: Zipcode, \n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
+		}, {
+			description:        "missing service mode",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(noServiceModeJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create entry as it must offer at least one service mode: takeout, curbside, delivery, drive_through\n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
+		}, {
+			description:        "listing with attributes",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(attributesCreateJSON),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       "1\n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
+		}, {
+			description:        "unknown payment method",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(invalidPaymentJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create entry as it contained missing or invalid fields: PaymentMethods[1], \n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
+		}, {
+			description:        "untranslatable field",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(invalidTranslationJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create entry as it contained invalid translations: \"name\" cannot be translated\n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
+		}, {
+			description:        "unknown attributes",
+			url:                "/restaurants/",
+			method:             "POST",
+			body:               strings.NewReader(unknownAttributeJSON),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create entry as it contained unknown attributes: raw, keto\n",
+			entityClient: mockEntityInterface{
+				mode: Success,
+			},
 		}, {
 			description:        "failed restaurant creation",
 			url:                "/restaurants/",
 			method:             "POST",
 			body:               strings.NewReader(validCreateJSON),
 			expectedStatusCode: http.StatusInternalServerError,
-			expectedBody:       "could not write restaurant to db\n",
+			expectedBody:       "There was a saving your entry: ID: <nil>\n",
 			entityClient: mockEntityInterface{
 				mode: Fail,
 			},
@@ -193,3 +582,109 @@ func TestCreateHandler(t *testing.T) {
 
 	runTestCases(t, tests)
 }
+
+func TestGetHandler(t *testing.T) {
+	restaurant := models.Restaurant{Name: "Bob's Burgers", Details: "Burger of the day"}
+	expectedGetReturn, _ := json.Marshal(restaurant)
+
+	localized := restaurant
+	localized.Details = "es: Burger of the day"
+	expectedLocalizedReturn, _ := json.Marshal(localized)
+
+	deletedAt := time.Now()
+	mergedInto := uint(7)
+	merged := models.Restaurant{Name: "Bobs Burgers", MergedInto: &mergedInto}
+	merged.DeletedAt = &deletedAt
+
+	rejectedAt := time.Now()
+	rejected := models.Restaurant{Name: "Cheap Watches", RejectedAt: &rejectedAt}
+
+	tests := []handlerTests{
+		{
+			description:        "get a restaurant",
+			url:                "/restaurants/1",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedGetReturn),
+			entityClient: mockEntityInterface{
+				mode:     Success,
+				testRest: &restaurant,
+			},
+		}, {
+			description:        "get a restaurant in spanish",
+			url:                "/restaurants/1?lang=es",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedLocalizedReturn),
+			entityClient: mockEntityInterface{
+				mode:     Success,
+				testRest: &restaurant,
+			},
+		}, {
+			description:        "unsupported language falls back",
+			url:                "/restaurants/1?lang=fr",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(expectedGetReturn),
+			entityClient: mockEntityInterface{
+				mode:     Success,
+				testRest: &restaurant,
+			},
+		}, {
+			description:        "restaurant not found",
+			url:                "/restaurants/1",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient: mockEntityInterface{
+				mode: NotFound,
+			},
+		}, {
+			description:        "rejected restaurant is not found",
+			url:                "/restaurants/1",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient: mockEntityInterface{
+				mode:     Success,
+				testRest: &rejected,
+			},
+		}, {
+			description:        "merged restaurant redirects",
+			url:                "/restaurants/3",
+			method:             "GET",
+			body:               nil,
+			expectedStatusCode: http.StatusMovedPermanently,
+			expectedBody:       "<a href=\"/restaurants/7\">Moved Permanently</a>.\n\n",
+			entityClient: mockEntityInterface{
+				mode:     Success,
+				testRest: &merged,
+			},
+		},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCreateRateLimited(t *testing.T) {
+	assert := assert.New(t)
+
+	c := NewController(mux.NewRouter(), mockEntityInterface{}, mockAttributeEntity{}, mockTranslationEntity{}, mockGeocoder{}, services.SubmissionNotifier{}, services.NewRateLimiter(2, time.Hour))
+
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("POST", "/restaurants/", strings.NewReader("{}"))
+		req.RemoteAddr = "203.0.113.9:5123"
+
+		rr := httptest.NewRecorder()
+		c.r.ServeHTTP(rr, req)
+		codes = append(codes, rr.Code)
+	}
+
+	assert.Equal([]int{http.StatusBadRequest, http.StatusBadRequest, http.StatusTooManyRequests}, codes)
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.businesses_ordering_channels_gix;
+
+ALTER TABLE public.businesses 
+DROP COLUMN ordering_channels;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE public.businesses
+ADD COLUMN ordering_channels JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+CREATE INDEX businesses_ordering_channels_gix ON public.businesses USING GIN ( ordering_channels );
\ No newline at end of file
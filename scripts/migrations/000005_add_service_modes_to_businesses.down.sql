@@ -0,0 +1,5 @@
+ALTER TABLE public.businesses
+DROP COLUMN takeout,
+DROP COLUMN curbside,
+DROP COLUMN delivery,
+DROP COLUMN drive_through;
@@ -0,0 +1,17 @@
+ALTER TABLE public.businesses
+ADD COLUMN takeout BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN curbside BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN delivery BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN drive_through BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- infer service modes from the free-text tags submitted so far
+UPDATE public.businesses SET
+    takeout = ARRAY(SELECT lower(trim(t)) FROM unnest(tags) AS t) && ARRAY['takeout', 'take-out', 'take out', 'carryout', 'carry-out', 'pickup', 'pick-up', 'to-go', 'to go']::text[],
+    curbside = ARRAY(SELECT lower(trim(t)) FROM unnest(tags) AS t) && ARRAY['curbside', 'curbside pickup', 'curbside-pickup', 'curb-side']::text[],
+    delivery = ARRAY(SELECT lower(trim(t)) FROM unnest(tags) AS t) && ARRAY['delivery', 'delivers', 'free delivery', 'no-contact delivery']::text[],
+    drive_through = ARRAY(SELECT lower(trim(t)) FROM unnest(tags) AS t) && ARRAY['drive-through', 'drive through', 'drive-thru', 'drive thru', 'drivethru']::text[]
+WHERE tags IS NOT NULL;
+
+-- every listing was submitted as open for takeout, so fall back to that
+UPDATE public.businesses SET takeout = TRUE
+WHERE NOT (takeout OR curbside OR delivery OR drive_through);
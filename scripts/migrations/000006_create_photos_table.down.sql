@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.photos;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS public.photos (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    url TEXT NOT NULL,
+    thumbnail_url TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    is_approved BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX photos_business_idx ON public.photos ( business_id );
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.status_updates;
\ No newline at end of file
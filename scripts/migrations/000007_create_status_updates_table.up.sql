@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS public.status_updates (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    type TEXT NOT NULL,
+    message TEXT NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    is_approved BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP,
+    CHECK ( ends_at > starts_at )
+);
+
+CREATE INDEX status_updates_business_idx ON public.status_updates ( business_id, ends_at );
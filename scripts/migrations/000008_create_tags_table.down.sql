@@ -0,0 +1,5 @@
+ALTER TABLE public.businesses
+ALTER COLUMN tags DROP NOT NULL,
+ALTER COLUMN tags DROP DEFAULT;
+
+DROP TABLE IF EXISTS public.tags;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS public.tags (
+    id serial PRIMARY KEY,
+    slug TEXT UNIQUE NOT NULL,
+    display_name TEXT NOT NULL,
+    synonyms TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX tags_synonyms_gix ON public.tags USING GIN ( synonyms );
+
+-- normalize existing tags to slugs, dropping the empty entries left behind by
+-- the old array encoding
+UPDATE public.businesses SET tags = ARRAY(
+    SELECT slug FROM (
+        SELECT trim(BOTH '-' FROM regexp_replace(lower(trim(t)), '[^a-z0-9]+', '-', 'g')) AS slug, n
+        FROM unnest(tags) WITH ORDINALITY AS u(t, n)
+    ) AS slugs
+    WHERE slug <> ''
+    GROUP BY slug
+    ORDER BY MIN(n)
+)
+WHERE tags IS NOT NULL;
+
+UPDATE public.businesses SET tags = '{}' WHERE tags IS NULL;
+
+ALTER TABLE public.businesses
+ALTER COLUMN tags SET DEFAULT '{}',
+ALTER COLUMN tags SET NOT NULL;
+
+-- seed the taxonomy with the tags already in use
+INSERT INTO public.tags (slug, display_name, created_at, updated_at)
+SELECT DISTINCT t, initcap(replace(t, '-', ' ')), NOW(), NOW()
+FROM public.businesses, unnest(tags) AS t
+ON CONFLICT (slug) DO NOTHING;
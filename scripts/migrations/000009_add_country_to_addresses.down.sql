@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS public.zipcodes_country_zipcode_idx;
+
+DELETE FROM public.zipcodes WHERE country <> 'US';
+
+ALTER TABLE public.zipcodes
+DROP COLUMN country;
+
+ALTER TABLE public.zipcodes
+ADD CONSTRAINT zipcodes_zipcode_key UNIQUE ( zipcode );
+
+CREATE INDEX zipcodes_idx ON public.zipcodes ( zipcode );
+
+ALTER TABLE public.businesses
+DROP COLUMN country;
@@ -0,0 +1,12 @@
+ALTER TABLE public.businesses
+ADD COLUMN country TEXT NOT NULL DEFAULT 'US';
+
+ALTER TABLE public.zipcodes
+ADD COLUMN country TEXT NOT NULL DEFAULT 'US';
+
+ALTER TABLE public.zipcodes
+DROP CONSTRAINT IF EXISTS zipcodes_zipcode_key;
+
+DROP INDEX IF EXISTS public.zipcodes_idx;
+
+CREATE UNIQUE INDEX zipcodes_country_zipcode_idx ON public.zipcodes ( country, zipcode );
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.businesses_phone_e164_idx;
+
+ALTER TABLE public.businesses
+DROP COLUMN phone_e164,
+DROP COLUMN phone_extension;
@@ -0,0 +1,18 @@
+ALTER TABLE public.businesses
+ADD COLUMN phone_e164 TEXT NOT NULL DEFAULT '',
+ADD COLUMN phone_extension TEXT NOT NULL DEFAULT '';
+
+-- backfill the North American numbers we can read unambiguously, anything
+-- else is normalized the next time the listing is saved
+UPDATE public.businesses SET phone_e164 = '+1' || right(digits, 10)
+FROM (
+    SELECT id AS digits_id, regexp_replace(phone, '\D', '', 'g') AS digits
+    FROM public.businesses
+    WHERE country IN ('US', 'CA')
+) AS phone_digits
+WHERE id = digits_id
+AND (length(digits) = 10 OR (length(digits) = 11 AND digits LIKE '1%'))
+AND substr(right(digits, 10), 1, 1) BETWEEN '2' AND '9'
+AND substr(right(digits, 10), 4, 1) BETWEEN '2' AND '9';
+
+CREATE INDEX businesses_phone_e164_idx ON public.businesses ( phone_e164 );
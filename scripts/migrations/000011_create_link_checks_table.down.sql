@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.link_checks;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS public.link_checks (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    field TEXT NOT NULL,
+    url TEXT NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    redirect_url TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    is_broken BOOLEAN NOT NULL DEFAULT FALSE,
+    checked_at TIMESTAMP NOT NULL,
+    UNIQUE ( business_id, field, url )
+);
+
+CREATE INDEX link_checks_broken_idx ON public.link_checks ( is_broken );
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.businesses_name_trgm_idx;
+
+ALTER TABLE public.businesses
+DROP COLUMN duplicate_candidates;
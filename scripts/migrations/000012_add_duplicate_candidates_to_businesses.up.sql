@@ -0,0 +1,6 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE public.businesses
+ADD COLUMN duplicate_candidates INTEGER[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX businesses_name_trgm_idx ON public.businesses USING GIN ( name gin_trgm_ops );
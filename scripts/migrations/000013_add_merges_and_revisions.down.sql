@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.business_revisions;
+
+ALTER TABLE public.businesses
+DROP COLUMN merged_into;
@@ -0,0 +1,15 @@
+ALTER TABLE public.businesses
+ADD COLUMN merged_into INTEGER REFERENCES public.businesses ( id );
+
+CREATE TABLE IF NOT EXISTS public.business_revisions (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    original_business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    source TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    changes JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX business_revisions_business_idx ON public.business_revisions ( business_id, created_at );
@@ -0,0 +1,4 @@
+ALTER TABLE public.businesses
+DROP COLUMN is_verified;
+
+DROP TABLE IF EXISTS public.owners;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS public.owners (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    email TEXT NOT NULL,
+    verified_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE ( business_id, email )
+);
+
+CREATE INDEX owners_email_idx ON public.owners ( lower(email) );
+
+ALTER TABLE public.businesses
+ADD COLUMN is_verified BOOLEAN NOT NULL DEFAULT FALSE;
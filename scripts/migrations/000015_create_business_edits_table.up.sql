@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS public.business_edits (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    source TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewed_by TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX business_edits_business_idx ON public.business_edits ( business_id, status );
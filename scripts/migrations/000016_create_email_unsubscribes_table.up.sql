@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS public.email_unsubscribes (
+    email TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL
+);
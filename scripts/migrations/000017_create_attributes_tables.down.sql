@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.business_attributes;
+DROP TABLE IF EXISTS public.attributes;
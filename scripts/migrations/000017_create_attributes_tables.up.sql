@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS public.attributes (
+    id serial PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    category TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS public.business_attributes (
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    attribute_id INTEGER NOT NULL REFERENCES public.attributes ( id ) ON DELETE CASCADE,
+    PRIMARY KEY ( business_id, attribute_id )
+);
+
+CREATE INDEX business_attributes_attribute_idx ON public.business_attributes ( attribute_id );
+
+INSERT INTO public.attributes (slug, name, category, created_at) VALUES
+    ('vegetarian', 'Vegetarian', 'dietary', NOW()),
+    ('vegan', 'Vegan', 'dietary', NOW()),
+    ('gluten_free', 'Gluten-free', 'dietary', NOW()),
+    ('halal', 'Halal', 'dietary', NOW()),
+    ('kosher', 'Kosher', 'dietary', NOW()),
+    ('wheelchair_accessible_pickup', 'Wheelchair-accessible pickup', 'accessibility', NOW());
@@ -0,0 +1,2 @@
+ALTER TABLE public.businesses
+DROP COLUMN payment_methods;
@@ -0,0 +1,4 @@
+ALTER TABLE public.businesses
+ADD COLUMN payment_methods TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX businesses_payment_methods_idx ON public.businesses USING GIN ( payment_methods );
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS public.translations (
+    id serial PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    locale TEXT NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE ( entity_type, entity_id, field, locale )
+);
@@ -0,0 +1,5 @@
+ALTER TABLE public.businesses
+DROP COLUMN is_hidden,
+DROP COLUMN hidden_by;
+
+DROP TABLE IF EXISTS public.reports;
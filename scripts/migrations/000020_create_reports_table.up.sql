@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS public.reports (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    reason TEXT NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    reporter_hash TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewed_by TEXT,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX reports_business_idx ON public.reports ( business_id, status );
+
+ALTER TABLE public.businesses
+ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN hidden_by TEXT;
@@ -0,0 +1,5 @@
+ALTER TABLE public.businesses
+DROP COLUMN last_confirmed_at,
+DROP COLUMN owner_updated_at;
+
+DROP TABLE IF EXISTS public.confirmations;
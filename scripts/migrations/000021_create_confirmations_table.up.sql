@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.confirmations (
+    id serial PRIMARY KEY,
+    business_id INTEGER NOT NULL REFERENCES public.businesses ( id ),
+    client_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX confirmations_client_idx ON public.confirmations ( business_id, client_hash, created_at );
+
+ALTER TABLE public.businesses
+ADD COLUMN last_confirmed_at TIMESTAMP,
+ADD COLUMN owner_updated_at TIMESTAMP;
@@ -0,0 +1,4 @@
+ALTER TABLE public.businesses
+DROP COLUMN rejected_at,
+DROP COLUMN rejected_by,
+DROP COLUMN rejection_reason;
@@ -0,0 +1,4 @@
+ALTER TABLE public.businesses
+ADD COLUMN rejected_at TIMESTAMP,
+ADD COLUMN rejected_by TEXT,
+ADD COLUMN rejection_reason TEXT;
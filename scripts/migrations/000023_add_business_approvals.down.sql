@@ -0,0 +1,3 @@
+ALTER TABLE public.businesses
+DROP COLUMN approved_at,
+DROP COLUMN approved_by;
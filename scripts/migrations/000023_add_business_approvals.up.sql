@@ -0,0 +1,3 @@
+ALTER TABLE public.businesses
+ADD COLUMN approved_at TIMESTAMP,
+ADD COLUMN approved_by TEXT;
@@ -0,0 +1,7 @@
+ALTER TABLE public.photos
+DROP COLUMN approved_at,
+DROP COLUMN approved_by;
+
+ALTER TABLE public.status_updates
+DROP COLUMN approved_at,
+DROP COLUMN approved_by;
@@ -0,0 +1,7 @@
+ALTER TABLE public.photos
+ADD COLUMN approved_at TIMESTAMP,
+ADD COLUMN approved_by TEXT;
+
+ALTER TABLE public.status_updates
+ADD COLUMN approved_at TIMESTAMP,
+ADD COLUMN approved_by TEXT;
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -16,9 +21,18 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/rs/cors"
+	"github.com/theproducer/openfortakeout_api/admin"
+	"github.com/theproducer/openfortakeout_api/attributes"
+	"github.com/theproducer/openfortakeout_api/confirmations"
+	"github.com/theproducer/openfortakeout_api/notifications"
+	"github.com/theproducer/openfortakeout_api/owners"
+	"github.com/theproducer/openfortakeout_api/photos"
+	"github.com/theproducer/openfortakeout_api/reports"
 	"github.com/theproducer/openfortakeout_api/restaurants"
 	"github.com/theproducer/openfortakeout_api/services"
 	"github.com/theproducer/openfortakeout_api/slackadmin"
+	"github.com/theproducer/openfortakeout_api/statusupdates"
+	"github.com/theproducer/openfortakeout_api/suggestions"
 )
 
 type Server struct {
@@ -55,8 +69,113 @@ func (s *Server) Initialize(user, password, dbName, host, port string) error {
 		DB:     s.DB,
 	}
 
-	restaurants.NewController(s.Router, re, geocoder)
-	slackadmin.NewController(s.Router, re)
+	signer := services.TokenSigner{
+		Secret: []byte(os.Getenv("TOKEN_SECRET")),
+	}
+
+	hasher := services.ClientHasher{
+		Secret: []byte(os.Getenv("TOKEN_SECRET")),
+	}
+
+	pe := services.PhotoEntity{
+		DB:     s.DB,
+		Signer: signer,
+	}
+
+	se := services.StatusUpdateEntity{
+		DB: s.DB,
+	}
+
+	storage := services.LocalStorage{
+		Dir:     os.Getenv("MEDIA_DIR"),
+		BaseURL: os.Getenv("MEDIA_URL"),
+	}
+
+	// an empty or relative directory would end up serving the working directory
+	if !filepath.IsAbs(storage.Dir) {
+		return errors.New("MEDIA_DIR must be an absolute path")
+	}
+
+	te := services.TagEntity{
+		DB: s.DB,
+	}
+
+	adminAuth := services.NewAdminAuth(os.Getenv("ADMIN_TOKENS"))
+
+	oe := services.OwnerEntity{
+		DB: s.DB,
+	}
+
+	mailer := services.NewMailQueue(services.SMTPMailer{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+	}, services.DefaultMailQueueSize)
+	mailer.Start(context.Background())
+
+	ue := services.UnsubscribeEntity{
+		DB: s.DB,
+	}
+
+	notifier := services.SubmissionNotifier{
+		Mailer:       mailer,
+		Unsubscribes: ue,
+		Signer:       signer,
+		BaseURL:      os.Getenv("PUBLIC_API_URL"),
+	}
+
+	ae := services.AttributeEntity{
+		DB: s.DB,
+	}
+
+	tre := services.TranslationEntity{
+		DB: s.DB,
+	}
+
+	ee := services.EditEntity{
+		DB: s.DB,
+	}
+
+	rpe := services.ReportEntity{
+		DB: s.DB,
+	}
+
+	if threshold, err := strconv.Atoi(os.Getenv("REPORT_HIDE_THRESHOLD")); err == nil {
+		rpe.HideThreshold = threshold
+	}
+
+	cfe := services.ConfirmationEntity{
+		DB: s.DB,
+	}
+
+	linkChecker := services.LinkChecker{
+		DB:          s.DB,
+		Client:      services.NewLinkCheckClient(),
+		Timeout:     services.DefaultLinkCheckTimeout,
+		Concurrency: services.DefaultLinkCheckConcurrency,
+		NotifySlack: os.Getenv("LINK_CHECK_SLACK") == "true",
+	}
+
+	if interval, err := time.ParseDuration(os.Getenv("LINK_CHECK_INTERVAL")); err == nil && interval > 0 {
+		linkChecker.Start(context.Background(), interval)
+	}
+
+	restaurants.NewController(s.Router, re, ae, tre, &geocoder, notifier, services.NewRateLimiter(restaurants.CreateLimit, restaurants.CreateLimitWindow))
+	attributes.NewController(s.Router, ae)
+	photos.NewController(s.Router, re, pe, storage, signer)
+	statusupdates.NewController(s.Router, re, se, services.OwnerAuth{Signer: signer})
+	reports.NewController(s.Router, re, rpe, services.NewRateLimiter(reports.ReportLimit, reports.ReportLimitWindow), hasher)
+	confirmations.NewController(s.Router, re, cfe, services.NewRateLimiter(confirmations.ConfirmationLimit, confirmations.ConfirmationLimitWindow), hasher)
+	suggestions.NewController(s.Router, re, ee, &geocoder, services.NewRateLimiter(suggestions.SuggestionLimit, suggestions.SuggestionLimitWindow), hasher)
+	owners.NewController(s.Router, re, oe, ee, &geocoder, mailer, signer, services.NewRateLimiter(owners.EmailLimit, owners.EmailLimitWindow), os.Getenv("PUBLIC_API_URL"))
+	notifications.NewController(s.Router, ue, signer)
+	slackadmin.NewController(s.Router, re, pe, se, ee, rpe, &geocoder, services.SlackAPIClient{Token: os.Getenv("SLACK_BOT_TOKEN")}, notifier, services.SlackVerifier{
+		SigningSecret: []byte(os.Getenv("SLACK_SIGNING_SECRET")),
+		ReplayWindow:  services.DefaultSlackReplayWindow,
+	})
+	admin.NewController(s.Router, adminAuth, re, te, linkChecker, notifier)
 
 	return nil
 }
@@ -87,12 +206,18 @@ func (s *Server) RunMigrations(db *sql.DB, dbName string) error {
 func (s *Server) Run(addr string, origins []string) {
 	c := cors.New(cors.Options{
 		AllowedOrigins: origins,
-		AllowedHeaders: []string{"X-Requested-With", "Content-Type", "Authorization"},
-		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "OPTIONS", "DELETE"},
+		AllowedHeaders: []string{"X-Requested-With", "Content-Type", "Authorization", confirmations.DeviceHeader},
+		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "OPTIONS", "DELETE"},
 		Debug:          true,
 	})
 
 	serverHandler := c.Handler(s.Router)
+	// behind the reverse proxy the client address comes from X-Forwarded-For,
+	// which reports are rate limited by
+	if os.Getenv("TRUST_PROXY") == "true" {
+		serverHandler = handlers.ProxyHeaders(serverHandler)
+	}
+
 	loggedHandler := handlers.LoggingHandler(os.Stdout, serverHandler)
 
 	log.Printf("Starting server at %v\n", addr)
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+type adminContextKey struct{}
+
+// AdminAuth authenticates admin API requests with bearer tokens. Each token
+// belongs to a named admin so actions can be attributed to them.
+type AdminAuth struct {
+	Tokens map[string]string
+}
+
+// NewAdminAuth parses a comma-separated list of name:token pairs, as found in
+// the ADMIN_TOKENS environment variable.
+func NewAdminAuth(config string) AdminAuth {
+	auth := AdminAuth{
+		Tokens: map[string]string{},
+	}
+
+	for _, pair := range strings.Split(config, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		auth.Tokens[parts[1]] = parts[0]
+	}
+
+	return auth
+}
+
+// Authenticate returns the admin name for a request's bearer token, or an
+// empty string if the token is missing or unknown.
+func (a AdminAuth) Authenticate(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+
+	for knownToken, name := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(knownToken)) == 1 {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func (a AdminAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := a.Authenticate(r)
+		if name == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), adminContextKey{}, name)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AdminFromContext returns the name of the admin making the request.
+func AdminFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(adminContextKey{}).(string)
+	return name
+}
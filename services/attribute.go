@@ -0,0 +1,70 @@
+package services
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+type AttributeEntity struct {
+	DB *sqlx.DB
+}
+
+func (e AttributeEntity) GetAttributes() (*[]models.Attribute, error) {
+	attributes := []models.Attribute{}
+	query := `SELECT * FROM attributes ORDER BY category, name`
+	if err := e.DB.Select(&attributes, query); err != nil {
+		return nil, err
+	}
+
+	return &attributes, nil
+}
+
+// getBusinessAttributes returns the attribute slugs of each listing that has
+// any.
+func (e AttributeEntity) getBusinessAttributes(restaurantIDs []uint) (map[uint][]string, error) {
+	attributes := map[uint][]string{}
+	if len(restaurantIDs) == 0 {
+		return attributes, nil
+	}
+
+	ids := make([]int64, len(restaurantIDs))
+	for i, id := range restaurantIDs {
+		ids[i] = int64(id)
+	}
+
+	query := `SELECT business_attributes.business_id, attributes.slug FROM business_attributes
+		JOIN attributes ON attributes.id = business_attributes.attribute_id
+		WHERE business_attributes.business_id = ANY($1)
+		ORDER BY attributes.slug`
+
+	rows, err := e.DB.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var businessID uint
+		var slug string
+		if err := rows.Scan(&businessID, &slug); err != nil {
+			return nil, err
+		}
+		attributes[businessID] = append(attributes[businessID], slug)
+	}
+
+	return attributes, rows.Err()
+}
+
+// setBusinessAttributes replaces a listing's attributes. Unknown slugs are
+// ignored, so callers should validate them first.
+func setBusinessAttributes(db sqlx.Execer, restaurantID uint, slugs []string) error {
+	if _, err := db.Exec(`DELETE FROM business_attributes WHERE business_id = $1`, restaurantID); err != nil {
+		return err
+	}
+
+	insert := `INSERT INTO business_attributes (business_id, attribute_id)
+		SELECT $1, id FROM attributes WHERE slug = ANY($2)`
+	_, err := db.Exec(insert, restaurantID, pq.StringArray(slugs))
+	return err
+}
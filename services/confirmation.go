@@ -0,0 +1,63 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ConfirmationDedupeWindow is how long a device or address has to wait before
+// its next confirmation of the same listing counts.
+const ConfirmationDedupeWindow = 24 * time.Hour
+
+type ConfirmationEntity struct {
+	DB *sqlx.DB
+}
+
+// ConfirmOpen records that someone saw the listing open and returns when it
+// was last confirmed. Repeat confirmations from the same client within the
+// dedupe window are not counted, which the returned bool reports.
+func (e ConfirmationEntity) ConfirmOpen(restaurantID uint, clientHash string) (bool, *time.Time, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return false, nil, err
+	}
+	defer tx.Rollback()
+
+	// locking the listing serializes confirmations from the same client
+	var lastConfirmedAt *time.Time
+	if err := tx.Get(&lastConfirmedAt, `SELECT last_confirmed_at FROM businesses WHERE id = $1 FOR UPDATE`, restaurantID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	now := time.Now()
+
+	var recent bool
+	query := `SELECT EXISTS (SELECT 1 FROM confirmations WHERE business_id = $1 AND client_hash = $2 AND created_at > $3)`
+	if err := tx.Get(&recent, query, restaurantID, clientHash, now.Add(-ConfirmationDedupeWindow)); err != nil {
+		return false, nil, err
+	}
+
+	if recent {
+		return false, lastConfirmedAt, nil
+	}
+
+	insert := `INSERT INTO confirmations (business_id, client_hash, created_at) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(insert, restaurantID, clientHash, now); err != nil {
+		return false, nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE businesses SET last_confirmed_at = $1 WHERE id = $2`, now, restaurantID); err != nil {
+		return false, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, nil, err
+	}
+
+	return true, &now, nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+type EditEntity struct {
+	DB *sqlx.DB
+}
+
+// CreateEdit stores a proposed change without applying it and asks the
+// moderators to review it.
+func (e EditEntity) CreateEdit(newEdit models.BusinessEdit, restaurant models.Restaurant) (*uint, error) {
+	insert := `INSERT INTO business_edits (
+		business_id,
+		source,
+		actor,
+		changes,
+		status,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	var newID uint
+
+	err := e.DB.QueryRow(
+		insert,
+		newEdit.BusinessID,
+		newEdit.Source,
+		newEdit.Actor,
+		newEdit.Changes,
+		models.EditPending,
+		time.Now(),
+	).Scan(&newID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.CreateEditMsg(newEdit, restaurant, strconv.Itoa(int(newID)))
+
+	return &newID, nil
+}
+
+// ApproveEdit applies a pending edit through the normal update path. An edit
+// that has already been reviewed is returned as is.
+func (e EditEntity) ApproveEdit(editID uint, reviewer string) (*models.BusinessEdit, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var edit models.BusinessEdit
+	if err := tx.QueryRowx(`SELECT * FROM business_edits WHERE id = $1 FOR UPDATE`, editID).StructScan(&edit); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if edit.Status != models.EditPending {
+		return &edit, nil
+	}
+
+	updated, err := RestaurantEntity{DB: e.DB}.updateRestaurant(tx, edit.BusinessID, edit.Changes, models.Revision{
+		Source:  edit.Source,
+		Actor:   edit.Actor,
+		Summary: fmt.Sprintf("Edit #%d by %s approved by %s", edit.ID, edit.Actor, reviewer),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	edit.Status = models.EditApproved
+	edit.ReviewedBy = &reviewer
+	edit.ReviewedAt = &now
+
+	update := `UPDATE business_edits SET status = $1, reviewed_by = $2, reviewed_at = $3 WHERE id = $4`
+	if _, err := tx.Exec(update, edit.Status, reviewer, now, edit.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &edit, nil
+}
+
+// RejectEdit discards a pending edit without applying it. An edit that has
+// already been reviewed is returned as is.
+func (e EditEntity) RejectEdit(editID uint, reviewer string) (*models.BusinessEdit, error) {
+	now := time.Now()
+
+	update := `UPDATE business_edits SET status = $1, reviewed_by = $2, reviewed_at = $3 WHERE id = $4 AND status = $5`
+	if _, err := e.DB.Exec(update, models.EditRejected, reviewer, now, editID, models.EditPending); err != nil {
+		return nil, err
+	}
+
+	var edit models.BusinessEdit
+	if err := e.DB.QueryRowx(`SELECT * FROM business_edits WHERE id = $1`, editID).StructScan(&edit); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &edit, nil
+}
+
+func (e EditEntity) CreateEditMsg(edit models.BusinessEdit, restaurant models.Restaurant, editID string) {
+	msg := models.SlackMsg{}
+
+	proposed := restaurant
+	edit.Changes.Apply(&proposed)
+
+	changes := models.DiffRestaurants(restaurant, proposed)
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	lines := []string{}
+	for _, field := range fields {
+		lines = append(lines, fmt.Sprintf("*%s*\nBefore: %s\nAfter: %s", field, formatDiffValue(changes[field].Before), formatDiffValue(changes[field].After)))
+	}
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "section",
+		Text: &models.SlackMsgText{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf(
+				"An edit has been submitted for *%s* (#%d) by %s (%s):\n\n%s",
+				restaurant.Name,
+				restaurant.ID,
+				edit.Actor,
+				edit.Source,
+				strings.Join(lines, "\n\n"),
+			),
+		},
+	})
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "actions",
+		Elements: &[]models.SlackMsgAction{
+			{
+				ActionID: models.SlackActionApproveEdit,
+				Type:     "button",
+				Style:    "primary",
+				Value:    editID,
+				Text: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Accept",
+				},
+			},
+			{
+				ActionID: models.SlackActionRejectEdit,
+				Type:     "button",
+				Style:    "danger",
+				Value:    editID,
+				Text: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Reject",
+				},
+			},
+		},
+	})
+
+	postSlackMsg(msg)
+}
+
+// formatDiffValue renders one side of a changed field for Slack, making empty
+// values visible. Suggestions come from anyone, so text is escaped; JSON
+// encoding already escapes <, > and &.
+func formatDiffValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "(empty)"
+	case string:
+		if v == "" {
+			return "(empty)"
+		}
+		return escapeSlack(v)
+	case []interface{}:
+		if len(v) == 0 {
+			return "(empty)"
+		}
+	}
+
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDiffValue(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("(empty)", formatDiffValue(nil))
+	assert.Equal("(empty)", formatDiffValue(""))
+	assert.Equal("(empty)", formatDiffValue([]interface{}{}))
+	assert.Equal("11am &amp; later", formatDiffValue("11am & later"))
+	assert.Equal("&lt;!channel&gt; &lt;https://evil|Approve&gt;", formatDiffValue("<!channel> <https://evil|Approve>"))
+	assert.Equal(`["\u003c!here\u003e"]`, formatDiffValue([]interface{}{"<!here>"}))
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/theproducer/openfortakeout_api/models"
 )
 
@@ -17,13 +18,25 @@ type GeocodioService struct {
 	DB     *sqlx.DB
 }
 
-func (g *GeocodioService) GeocodeAddress(street, city, state, zipcode string) (*models.GeoPoint, error) {
+// geocodioCountries lists the countries Geocodio can geocode. Addresses
+// elsewhere are saved without a location.
+var geocodioCountries = map[string]bool{
+	"US": true,
+	"CA": true,
+}
+
+func (g *GeocodioService) GeocodeAddress(street, city, state, zipcode, country string) (*models.GeoPoint, error) {
+	if !geocodioCountries[country] {
+		return nil, nil
+	}
+
 	geocodioURL := fmt.Sprintf(
-		"https://api.geocod.io/v1.4/geocode?street=%s&city=%s&state=%s&postal_code=%s&api_key=%s",
+		"https://api.geocod.io/v1.4/geocode?street=%s&city=%s&state=%s&postal_code=%s&country=%s&api_key=%s",
 		url.QueryEscape(street),
 		url.QueryEscape(city),
 		url.QueryEscape(state),
 		url.QueryEscape(zipcode),
+		url.QueryEscape(country),
 		g.APIKey,
 	)
 	resp, err := http.Get(geocodioURL)
@@ -45,24 +58,31 @@ func (g *GeocodioService) GeocodeAddress(street, city, state, zipcode string) (*
 
 	return &result.Results[0].Location, nil
 }
-func (g *GeocodioService) GeocodeZipcode(zipcode string) (*models.GeoPoint, error) {
-	point, err := g.LookupZipcode(zipcode)
+func (g *GeocodioService) GeocodeZipcode(country, zipcode string) (*models.GeoPoint, error) {
+	point, err := g.LookupZipcode(country, zipcode)
 	if err != nil {
 		return nil, fmt.Errorf("GeocodioService: %v", err)
 	}
 
 	if point == nil {
-		sentry.CaptureMessage(fmt.Sprintf("Geocoding zipcode: %s\n", zipcode))
-		fmt.Printf("Geocoding zipcode: %s\n", zipcode)
-		return g.GeocodeAddress("", "", "", zipcode)
+		sentry.CaptureMessage(fmt.Sprintf("Geocoding zipcode: %s %s\n", country, zipcode))
+		return g.GeocodeAddress("", "", "", zipcode, country)
 	}
 
 	return point, nil
 }
 
-func (g *GeocodioService) LookupZipcode(zipcode string) (*models.GeoPoint, error) {
-	query := `SELECT * FROM zipcodes WHERE zipcode = $1`
-	row := g.DB.QueryRowx(query, zipcode)
+// LookupZipcode finds a postal code in the zipcodes table. Canadian postal
+// codes fall back to their forward sortation area.
+func (g *GeocodioService) LookupZipcode(country, zipcode string) (*models.GeoPoint, error) {
+	query := `SELECT * FROM zipcodes WHERE country = $1 AND zipcode = ANY($2) ORDER BY length(zipcode) DESC LIMIT 1`
+
+	candidates := []string{zipcode}
+	if country == "CA" && len(zipcode) > 3 {
+		candidates = append(candidates, models.FSA(zipcode))
+	}
+
+	row := g.DB.QueryRowx(query, country, pq.Array(candidates))
 	var zipResult models.Zipcode
 	err := row.StructScan(&zipResult)
 	if err != nil {
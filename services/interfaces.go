@@ -1,17 +1,100 @@
 package services
 
 import (
+	"io"
+	"time"
+
 	"github.com/theproducer/openfortakeout_api/models"
 )
 
 type RestaurantEntityInterface interface {
 	CreateRestaurant(newRestaurant models.Restaurant) (*uint, error)
-	ApproveRestaurant(restaurantID uint) (*models.Restaurant, error)
-	GetRestaurants(lat *float64, lng *float64) (*[]models.Restaurant, error)
+	ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error)
+	RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error)
+	GetRestaurants(lat *float64, lng *float64, filters models.RestaurantFilters) (*[]models.Restaurant, error)
 	GetRestaurant(id uint) (*models.Restaurant, error)
+	MergeRestaurants(targetID uint, sourceID uint, choices models.MergeChoices, actor string) (*models.Restaurant, map[uint]uint, error)
+	UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error)
+	GetSubmissions(status string) (*[]models.Restaurant, error)
+	SearchRestaurants(term string, limit int) (*[]models.Restaurant, error)
+	HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error)
+	GetModerationStats() (*models.ModerationStats, error)
 }
 
 type GeocodioServiceInterface interface {
-	GeocodeAddress(street, city, state, zipcode string) (*models.GeoPoint, error)
-	GeocodeZipcode(zipcode string) (*models.GeoPoint, error)
+	GeocodeAddress(street, city, state, zipcode, country string) (*models.GeoPoint, error)
+	GeocodeZipcode(country, zipcode string) (*models.GeoPoint, error)
+}
+
+type PhotoEntityInterface interface {
+	CreatePhoto(newPhoto models.Photo) (*uint, error)
+	ApprovePhoto(photoID uint, reviewer string) (*models.Photo, bool, error)
+	GetPhotoByKey(key string) (*models.Photo, error)
+}
+
+type BlobStorage interface {
+	Put(key string, data io.Reader) error
+	Delete(key string) error
+	Open(key string) (Blob, error)
+	URL(key string) string
+}
+
+type StatusUpdateEntityInterface interface {
+	CreateStatusUpdate(newStatus models.StatusUpdate, restaurant models.Restaurant) (*uint, error)
+	ApproveStatusUpdate(statusID uint, reviewer string) (*models.StatusUpdate, bool, error)
+}
+
+type TagEntityInterface interface {
+	GetTags() (*[]models.Tag, error)
+	GetTag(slug string) (*models.Tag, error)
+	CreateTag(newTag models.Tag) (*models.Tag, error)
+	RenameTag(slug string, renamed models.Tag) (*models.Tag, error)
+	MergeTags(sourceSlug string, targetSlug string) (*models.Tag, error)
+	NormalizeTags(tags []string) ([]string, error)
+}
+
+type AttributeEntityInterface interface {
+	GetAttributes() (*[]models.Attribute, error)
+}
+
+type TranslationEntityInterface interface {
+	LocalizeRestaurants(restaurants []models.Restaurant, locale string) error
+}
+
+type ReportEntityInterface interface {
+	CreateReport(newReport models.Report, restaurant models.Restaurant) (*models.Report, error)
+	HideReported(reportID uint, reviewer string) (*models.Report, error)
+	DismissReport(reportID uint, reviewer string) (*models.Report, error)
+}
+
+type ConfirmationEntityInterface interface {
+	ConfirmOpen(restaurantID uint, clientHash string) (bool, *time.Time, error)
+}
+
+type LinkCheckerInterface interface {
+	GetBrokenLinks() (*[]models.LinkCheck, error)
+}
+
+type OwnerEntityInterface interface {
+	CreateOwner(restaurantID uint, email string) (*models.Owner, error)
+	GetOwnedRestaurantIDs(email string) ([]uint, error)
+}
+
+type EditEntityInterface interface {
+	CreateEdit(newEdit models.BusinessEdit, restaurant models.Restaurant) (*uint, error)
+	ApproveEdit(editID uint, reviewer string) (*models.BusinessEdit, error)
+	RejectEdit(editID uint, reviewer string) (*models.BusinessEdit, error)
+}
+
+type Mailer interface {
+	Send(email models.Email) error
+}
+
+type SlackClient interface {
+	OpenView(triggerID string, view models.SlackView) error
+}
+
+type UnsubscribeEntityInterface interface {
+	Unsubscribe(email string) error
+	IsUnsubscribed(email string) (bool, error)
 }
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+const (
+	DefaultLinkCheckTimeout     = 10 * time.Second
+	DefaultLinkCheckConcurrency = 8
+
+	linkCheckUserAgent = "OpenForTakeoutLinkChecker/1.0"
+	maxLinkRedirects   = 10
+)
+
+// ErrNonPublicAddress is returned when a link resolves to an address the
+// checker shouldn't reach, like loopback, private networks or cloud metadata.
+var ErrNonPublicAddress = errors.New("link resolves to a non-public address")
+
+// nonPublicNetworks are ranges a submitted link must never make the server
+// request.
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/3",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// LinkChecker periodically requests every URL stored on a listing and records
+// whether it still resolves.
+type LinkChecker struct {
+	DB          *sqlx.DB
+	Client      *http.Client
+	Timeout     time.Duration
+	Concurrency int
+	NotifySlack bool
+}
+
+// Start runs a check immediately and then once per interval until ctx is done.
+func (l LinkChecker) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := l.Run(ctx); err != nil {
+				sentry.CaptureException(fmt.Errorf("LinkChecker: %v", err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run checks every stored link, saves the results and returns the broken ones.
+func (l LinkChecker) Run(ctx context.Context) ([]models.LinkCheck, error) {
+	startedAt := time.Now()
+
+	links, err := l.storedLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	results := l.CheckLinks(ctx, links)
+
+	upsert := `INSERT INTO link_checks (business_id, field, url, status_code, redirect_url, error, is_broken, checked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (business_id, field, url) DO UPDATE SET
+			status_code = EXCLUDED.status_code,
+			redirect_url = EXCLUDED.redirect_url,
+			error = EXCLUDED.error,
+			is_broken = EXCLUDED.is_broken,
+			checked_at = EXCLUDED.checked_at`
+
+	broken := []models.LinkCheck{}
+	for _, result := range results {
+		_, err := l.DB.Exec(upsert, result.BusinessID, result.Field, result.URL, result.StatusCode, result.RedirectURL, result.Error, result.IsBroken, result.CheckedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.IsBroken {
+			broken = append(broken, result)
+		}
+	}
+
+	// drop results for links that have since been edited or removed
+	if _, err := l.DB.Exec(`DELETE FROM link_checks WHERE checked_at < $1`, startedAt); err != nil {
+		return nil, err
+	}
+
+	if l.NotifySlack {
+		l.CreateLinkCheckMsg(len(results), broken)
+	}
+
+	return broken, nil
+}
+
+func (l LinkChecker) GetBrokenLinks() (*[]models.LinkCheck, error) {
+	links := []models.LinkCheck{}
+
+	query := `SELECT link_checks.*, businesses.name AS business_name FROM link_checks
+		JOIN businesses ON businesses.id = link_checks.business_id
+		WHERE link_checks.is_broken IS TRUE AND businesses.deleted_at IS null
+		ORDER BY link_checks.business_id ASC, link_checks.field ASC`
+	if err := l.DB.Select(&links, query); err != nil {
+		return nil, err
+	}
+
+	return &links, nil
+}
+
+func (l LinkChecker) storedLinks() ([]models.LinkCheck, error) {
+	query := `SELECT id, 'url' AS field, url FROM businesses WHERE deleted_at IS null AND url <> ''
+		UNION ALL
+		SELECT id, 'donate_url' AS field, donate_url FROM businesses WHERE deleted_at IS null AND donate_url <> ''
+		UNION ALL
+		SELECT id, 'ordering_channels.' || (c->>'type') AS field, c->>'url' FROM businesses, jsonb_array_elements(ordering_channels) AS c
+		WHERE deleted_at IS null`
+
+	rows, err := l.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.LinkCheck{}
+	for rows.Next() {
+		var link models.LinkCheck
+		if err := rows.Scan(&link.BusinessID, &link.Field, &link.URL); err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(link.URL, "http://") || strings.HasPrefix(link.URL, "https://") {
+			links = append(links, link)
+		}
+	}
+
+	return links, rows.Err()
+}
+
+// CheckLinks checks each link, running at most Concurrency requests at once.
+func (l LinkChecker) CheckLinks(ctx context.Context, links []models.LinkCheck) []models.LinkCheck {
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultLinkCheckConcurrency
+	}
+
+	results := make([]models.LinkCheck, len(links))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, link := range links {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, link models.LinkCheck) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := l.CheckURL(ctx, link.URL)
+			result.BusinessID = link.BusinessID
+			result.Field = link.Field
+			results[i] = result
+		}(i, link)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// CheckURL issues a HEAD request, falling back to GET for servers that reject
+// HEAD, and reports the final status and where any redirects led.
+func (l LinkChecker) CheckURL(ctx context.Context, rawURL string) models.LinkCheck {
+	result := models.LinkCheck{
+		URL:       rawURL,
+		CheckedAt: time.Now(),
+	}
+
+	resp, err := l.request(ctx, http.MethodHead, rawURL)
+	if err != nil || resp.StatusCode >= 400 {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = l.request(ctx, http.MethodGet, rawURL)
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		result.IsBroken = true
+		return result
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	result.StatusCode = resp.StatusCode
+	result.IsBroken = resp.StatusCode >= 400
+
+	if finalURL := resp.Request.URL.String(); finalURL != rawURL {
+		result.RedirectURL = finalURL
+	}
+
+	return result
+}
+
+func (l LinkChecker) request(ctx context.Context, method string, rawURL string) (*http.Response, error) {
+	timeout := l.Timeout
+	if timeout <= 0 {
+		timeout = DefaultLinkCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	req, err := http.NewRequest(method, rawURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", linkCheckUserAgent)
+
+	resp, err := l.client().Do(req)
+	if err != nil {
+		cancel()
+		if urlErr, ok := err.(*url.Error); ok {
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+func (l LinkChecker) client() *http.Client {
+	client := l.Client
+	if client == nil {
+		client = NewLinkCheckClient()
+	}
+
+	checked := *client
+	checked.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxLinkRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxLinkRedirects)
+		}
+		return nil
+	}
+
+	return &checked
+}
+
+// NewLinkCheckClient returns a client that refuses to connect to loopback,
+// private or link-local addresses. The check runs on the resolved address of
+// every connection, so redirects and DNS answers can't get around it.
+func NewLinkCheckClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: DefaultLinkCheckTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if !isPublicIP(net.ParseIP(host)) {
+				return ErrNonPublicAddress
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: DefaultLinkCheckTimeout,
+			MaxIdleConns:        DefaultLinkCheckConcurrency,
+		},
+	}
+}
+
+func isPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+func (l LinkChecker) CreateLinkCheckMsg(checked int, broken []models.LinkCheck) {
+	msg := models.SlackMsg{}
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "section",
+		Text: &models.SlackMsgText{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("Link check finished: %d links checked, *%d broken*", checked, len(broken)),
+		},
+	})
+
+	if len(broken) > 0 {
+		var list strings.Builder
+		for i, link := range broken {
+			if i == 20 {
+				list.WriteString(fmt.Sprintf("…and %d more\n", len(broken)-i))
+				break
+			}
+
+			reason := link.Error
+			if reason == "" {
+				reason = fmt.Sprintf("HTTP %d", link.StatusCode)
+			}
+			list.WriteString(fmt.Sprintf("#%d %s: %s (%s)\n", link.BusinessID, link.Field, link.URL, reason))
+		}
+
+		msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+			Type: "section",
+			Text: &models.SlackMsgText{
+				Type: "mrkdwn",
+				Text: list.String(),
+			},
+		})
+	}
+
+	postSlackMsg(msg)
+}
+
+// cancelOnClose releases a request's timeout once its body has been closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
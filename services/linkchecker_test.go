@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+func linkCheckServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestCheckURL(t *testing.T) {
+	assert := assert.New(t)
+
+	server := linkCheckServer()
+	defer server.Close()
+
+	checker := LinkChecker{
+		Client:  server.Client(),
+		Timeout: 100 * time.Millisecond,
+	}
+
+	tests := []struct {
+		path        string
+		statusCode  int
+		broken      bool
+		redirectURL string
+	}{
+		{"/ok", http.StatusOK, false, ""},
+		{"/missing", http.StatusNotFound, true, ""},
+		{"/moved", http.StatusOK, false, server.URL + "/ok"},
+		{"/no-head", http.StatusOK, false, ""},
+		{"/slow", 0, true, ""},
+	}
+
+	for _, test := range tests {
+		result := checker.CheckURL(context.Background(), server.URL+test.path)
+		assert.Equal(test.statusCode, result.StatusCode, test.path)
+		assert.Equal(test.broken, result.IsBroken, test.path)
+		assert.Equal(test.redirectURL, result.RedirectURL, test.path)
+		assert.False(result.CheckedAt.IsZero(), test.path)
+	}
+
+	result := checker.CheckURL(context.Background(), server.URL+"/slow")
+	assert.Contains(result.Error, "deadline exceeded")
+}
+
+func TestCheckLinksConcurrency(t *testing.T) {
+	assert := assert.New(t)
+
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	links := []models.LinkCheck{}
+	for i := 0; i < 12; i++ {
+		links = append(links, models.LinkCheck{BusinessID: uint(i + 1), Field: "url", URL: server.URL})
+	}
+
+	checker := LinkChecker{
+		Client:      server.Client(),
+		Concurrency: 3,
+	}
+
+	results := checker.CheckLinks(context.Background(), links)
+
+	assert.Len(results, len(links))
+	for i, result := range results {
+		assert.Equal(uint(i+1), result.BusinessID)
+		assert.Equal("url", result.Field)
+		assert.False(result.IsBroken)
+	}
+	assert.True(atomic.LoadInt32(&maxInFlight) <= 3)
+}
+
+func TestLinkCheckClientRefusesNonPublicAddresses(t *testing.T) {
+	assert := assert.New(t)
+
+	server := linkCheckServer()
+	defer server.Close()
+
+	checker := LinkChecker{
+		Client:  NewLinkCheckClient(),
+		Timeout: 100 * time.Millisecond,
+	}
+
+	result := checker.CheckURL(context.Background(), server.URL+"/ok")
+	assert.True(result.IsBroken)
+	assert.Zero(result.StatusCode)
+	assert.Contains(result.Error, ErrNonPublicAddress.Error())
+
+	// the default client is guarded too
+	result = LinkChecker{}.CheckURL(context.Background(), server.URL+"/ok")
+	assert.Contains(result.Error, ErrNonPublicAddress.Error())
+}
+
+func TestIsPublicIP(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.20.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		assert.False(isPublicIP(net.ParseIP(ip)), ip)
+	}
+
+	for _, ip := range []string{"8.8.8.8", "93.184.216.34", "2606:4700:4700::1111"} {
+		assert.True(isPublicIP(net.ParseIP(ip)), ip)
+	}
+
+	assert.False(isPublicIP(nil))
+}
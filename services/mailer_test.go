@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+// fakeSMTPServer is a minimal local SMTP stand-in. It rejects the first
+// failures deliveries with a temporary error and records the rest.
+type fakeSMTPServer struct {
+	listener net.Listener
+	failures int
+
+	mu       sync.Mutex
+	attempts int
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T, failures int) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &fakeSMTPServer{listener: listener, failures: failures}
+	go server.serve()
+
+	return server
+}
+
+func (s *fakeSMTPServer) Close() {
+	s.listener.Close()
+}
+
+func (s *fakeSMTPServer) Mailer() SMTPMailer {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPMailer{Host: host, Port: port, From: "hello@example.com"}
+}
+
+func (s *fakeSMTPServer) Messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.messages...)
+}
+
+func (s *fakeSMTPServer) Attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(textproto.NewConn(conn))
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn *textproto.Conn) {
+	defer conn.Close()
+
+	conn.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+
+		switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+		case "EHLO", "HELO", "RCPT", "RSET", "NOOP":
+			conn.PrintfLine("250 OK")
+		case "MAIL":
+			s.mu.Lock()
+			s.attempts++
+			failed := s.attempts <= s.failures
+			s.mu.Unlock()
+
+			if failed {
+				conn.PrintfLine("451 try again later")
+				continue
+			}
+			conn.PrintfLine("250 OK")
+		case "DATA":
+			conn.PrintfLine("354 go ahead")
+			body, err := ioutil.ReadAll(conn.DotReader())
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.messages = append(s.messages, string(body))
+			s.mu.Unlock()
+			conn.PrintfLine("250 OK")
+		case "QUIT":
+			conn.PrintfLine("221 bye")
+			return
+		default:
+			conn.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSendsMultipart(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newFakeSMTPServer(t, 0)
+	defer server.Close()
+
+	err := server.Mailer().Send(models.Email{
+		To:      "bob@example.com",
+		Subject: "Hello",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>"},
+	})
+	assert.NoError(err)
+
+	messages := server.Messages()
+	if assert.Len(messages, 1) {
+		assert.Contains(messages[0], "To: bob@example.com")
+		assert.Contains(messages[0], "List-Unsubscribe: <https://example.com/u>")
+		assert.Contains(messages[0], "multipart/alternative")
+		assert.Contains(messages[0], "plain body")
+		assert.Contains(messages[0], "<p>html body</p>")
+	}
+}
+
+func TestMailQueueRetriesFailedDeliveries(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newFakeSMTPServer(t, 2)
+	defer server.Close()
+
+	queue := NewMailQueue(server.Mailer(), 10)
+	queue.RetryDelay = time.Millisecond
+	queue.Start(context.Background())
+
+	assert.NoError(queue.Send(models.Email{To: "bob@example.com", Subject: "Hello", Text: "body"}))
+	queue.Flush()
+
+	assert.Equal(3, server.Attempts())
+	assert.Len(server.Messages(), 1)
+}
+
+func TestMailQueueGivesUp(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newFakeSMTPServer(t, 100)
+	defer server.Close()
+
+	queue := NewMailQueue(server.Mailer(), 10)
+	queue.MaxAttempts = 3
+	queue.RetryDelay = time.Millisecond
+	queue.Start(context.Background())
+
+	assert.NoError(queue.Send(models.Email{To: "bob@example.com", Subject: "Hello", Text: "body"}))
+	queue.Flush()
+
+	assert.Equal(3, server.Attempts())
+	assert.Empty(server.Messages())
+}
+
+func TestMailQueueFull(t *testing.T) {
+	assert := assert.New(t)
+
+	// not started, so nothing drains the queue
+	queue := NewMailQueue(&MemoryMailer{}, 1)
+
+	assert.NoError(queue.Send(models.Email{To: "a@example.com"}))
+	assert.Equal(ErrMailQueueFull, queue.Send(models.Email{To: "b@example.com"}))
+}
+
+type mockUnsubscribes map[string]bool
+
+func (m mockUnsubscribes) Unsubscribe(email string) error {
+	m[email] = true
+	return nil
+}
+
+func (m mockUnsubscribes) IsUnsubscribed(email string) (bool, error) {
+	return m[email], nil
+}
+
+func TestSubmissionNotifier(t *testing.T) {
+	assert := assert.New(t)
+
+	mailer := &MemoryMailer{}
+	signer := TokenSigner{Secret: []byte("test-secret")}
+	notifier := SubmissionNotifier{
+		Mailer:       mailer,
+		Unsubscribes: mockUnsubscribes{"gone@example.com": true},
+		Signer:       signer,
+		BaseURL:      "https://api.example.com/",
+	}
+
+	restaurant := models.Restaurant{Name: "Bob's <Burgers>", Email: "Bob@Example.com"}
+
+	assert.NoError(notifier.SubmissionReceived(restaurant))
+	assert.NoError(notifier.SubmissionApproved(restaurant))
+
+	spam := models.RejectionSpam
+	restaurant.RejectionReason = &spam
+	assert.NoError(notifier.SubmissionRejected(restaurant))
+
+	duplicate := models.RejectionDuplicate
+	restaurant.RejectionReason = &duplicate
+	assert.NoError(notifier.SubmissionRejected(restaurant))
+
+	messages := mailer.Messages()
+	if !assert.Len(messages, 3) {
+		return
+	}
+
+	assert.Equal("We received your submission for Bob's <Burgers>", messages[0].Subject)
+	assert.Equal("Bob's <Burgers> is now listed on We're Open for Takeout", messages[1].Subject)
+	assert.Contains(messages[2].Text, "Reason: Duplicate listing")
+	assert.Contains(messages[2].HTML, "Reason: Duplicate listing")
+
+	for _, message := range messages {
+		assert.Equal("bob@example.com", message.To)
+		assert.Contains(message.Text, "Bob's <Burgers>")
+		assert.Contains(message.HTML, "Bob&#39;s &lt;Burgers&gt;")
+		assert.Contains(message.Text, "https://api.example.com/notifications/unsubscribe?token=")
+		assert.Contains(message.HTML, "https://api.example.com/notifications/unsubscribe?token=")
+		assert.Equal("List-Unsubscribe=One-Click", message.Headers["List-Unsubscribe-Post"])
+	}
+
+	header := messages[0].Headers["List-Unsubscribe"]
+	link, _ := url.Parse(strings.Trim(header, "<>"))
+	claims, err := signer.Verify(link.Query().Get("token"), TokenPurposeUnsubscribe)
+	if assert.NoError(err) {
+		assert.Equal("bob@example.com", claims.Email)
+	}
+
+	unsubscribed := models.Restaurant{Name: "Gone", Email: "gone@example.com"}
+	assert.NoError(notifier.SubmissionApproved(unsubscribed))
+	assert.Len(mailer.Messages(), 3)
+}
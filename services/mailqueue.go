@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+const (
+	DefaultMailQueueSize   = 100
+	DefaultMailMaxAttempts = 5
+	DefaultMailRetryDelay  = 30 * time.Second
+)
+
+var ErrMailQueueFull = errors.New("mail queue is full")
+
+type queuedEmail struct {
+	email   models.Email
+	attempt int
+}
+
+// MailQueue sends mail in the background through another Mailer, retrying
+// failed deliveries with exponential backoff so a slow or flaky relay never
+// holds up a request.
+type MailQueue struct {
+	Mailer      Mailer
+	MaxAttempts int
+	RetryDelay  time.Duration
+
+	queue   chan queuedEmail
+	pending sync.WaitGroup
+}
+
+func NewMailQueue(mailer Mailer, size int) *MailQueue {
+	return &MailQueue{
+		Mailer:      mailer,
+		MaxAttempts: DefaultMailMaxAttempts,
+		RetryDelay:  DefaultMailRetryDelay,
+		queue:       make(chan queuedEmail, size),
+	}
+}
+
+// Start delivers queued mail until ctx is done.
+func (q *MailQueue) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case item := <-q.queue:
+				q.deliver(ctx, item)
+			}
+		}
+	}()
+}
+
+// Send queues an email for delivery. It only fails if the queue is full.
+func (q *MailQueue) Send(email models.Email) error {
+	q.pending.Add(1)
+
+	select {
+	case q.queue <- queuedEmail{email: email, attempt: 1}:
+		return nil
+	default:
+		q.pending.Done()
+		return ErrMailQueueFull
+	}
+}
+
+// Flush waits until every queued email has been delivered or given up on.
+func (q *MailQueue) Flush() {
+	q.pending.Wait()
+}
+
+func (q *MailQueue) deliver(ctx context.Context, item queuedEmail) {
+	err := q.Mailer.Send(item.email)
+	if err == nil {
+		q.pending.Done()
+		return
+	}
+
+	if item.attempt >= q.MaxAttempts {
+		sentry.CaptureException(fmt.Errorf("MailQueue: giving up on mail to %s after %d attempts: %v", item.email.To, item.attempt, err))
+		q.pending.Done()
+		return
+	}
+
+	// retry later without blocking the rest of the queue
+	delay := q.RetryDelay << uint(item.attempt-1)
+	item.attempt++
+	time.AfterFunc(delay, func() {
+		select {
+		case <-ctx.Done():
+			q.pending.Done()
+		case q.queue <- item:
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+var (
+	ErrMergeSameRestaurant = errors.New("a restaurant cannot be merged into itself")
+	ErrMergeUnknownField   = errors.New("unknown merge field")
+)
+
+// mergeableFields maps each merge choice onto the businesses columns it
+// covers. Tags are always combined, so they are not listed here.
+var mergeableFields = map[string][]string{
+	"name":              {"name"},
+	"type":              {"type"},
+	"email":             {"email"},
+	"phone":             {"phone", "phone_e164", "phone_extension"},
+	"details":           {"details"},
+	"hours":             {"hours"},
+	"url":               {"url"},
+	"address":           {"address", "address2", "city", "state", "zipcode", "country", "location"},
+	"donate_url":        {"donate_url"},
+	"giftcard":          {"giftcard"},
+	"ordering_channels": {"ordering_channels"},
+	"service_modes":     {"takeout", "curbside", "delivery", "drive_through"},
+	"payment_methods":   {"payment_methods"},
+}
+
+// ValidateMergeChoices checks that every choice names a known field and
+// picks either the target or the source value.
+func ValidateMergeChoices(choices models.MergeChoices) error {
+	for field, choice := range choices {
+		if _, ok := mergeableFields[field]; !ok {
+			return fmt.Errorf("%w: %s", ErrMergeUnknownField, field)
+		}
+
+		if choice != models.MergeKeepTarget && choice != models.MergeTakeSource {
+			return fmt.Errorf("merge choice for %s must be %q or %q", field, models.MergeKeepTarget, models.MergeTakeSource)
+		}
+	}
+
+	return nil
+}
+
+// MergeRestaurants folds the source listing into the target. Chosen fields are
+// copied from the source, tags, attributes and owners are combined, photos,
+// status updates, edits, reports and revision history move to the target, and
+// the source is soft-deleted with a pointer to the target. It returns the
+// surviving listing and the ids that now redirect to it.
+func (e RestaurantEntity) MergeRestaurants(targetID uint, sourceID uint, choices models.MergeChoices, actor string) (*models.Restaurant, map[uint]uint, error) {
+	if targetID == sourceID {
+		return nil, nil, ErrMergeSameRestaurant
+	}
+
+	if err := ValidateMergeChoices(choices); err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	target, err := lockRestaurant(tx, targetID)
+	if err != nil || target == nil {
+		return nil, nil, err
+	}
+
+	source, err := lockRestaurant(tx, sourceID)
+	if err != nil || source == nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+
+	assignments := []string{
+		fmt.Sprintf("tags = %s", fmt.Sprintf(dedupeTags, "t.tags || s.tags")),
+		"updated_at = $3",
+		// freshness signals keep whichever listing saw the most recent activity
+		"last_confirmed_at = GREATEST(t.last_confirmed_at, s.last_confirmed_at)",
+		"owner_updated_at = GREATEST(t.owner_updated_at, s.owner_updated_at)",
+		"is_verified = t.is_verified OR s.is_verified",
+	}
+	for field, choice := range choices {
+		if choice != models.MergeTakeSource {
+			continue
+		}
+		for _, column := range mergeableFields[field] {
+			assignments = append(assignments, fmt.Sprintf("%s = s.%s", column, column))
+		}
+	}
+
+	update := fmt.Sprintf(`UPDATE businesses AS t SET %s FROM businesses AS s WHERE t.id = $1 AND s.id = $2`, strings.Join(assignments, ", "))
+	if _, err := tx.Exec(update, targetID, sourceID, now); err != nil {
+		return nil, nil, err
+	}
+
+	retire := `UPDATE businesses SET deleted_at = $1, is_active = FALSE, merged_into = $2, updated_at = $1 WHERE id = $3`
+	if _, err := tx.Exec(retire, now, targetID, sourceID); err != nil {
+		return nil, nil, err
+	}
+
+	err = recordRevision(tx, models.Revision{
+		BusinessID: sourceID,
+		Source:     models.RevisionSourceMerge,
+		Actor:      actor,
+		Summary:    fmt.Sprintf("Merged into #%d (%s)", targetID, target.Name),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// move everything attached to the source over to the survivor, keeping the
+	// original business id on revisions so both histories stay readable
+	statements := []string{
+		`UPDATE businesses SET merged_into = $1 WHERE merged_into = $2`,
+		`UPDATE photos SET business_id = $1 WHERE business_id = $2`,
+		`UPDATE status_updates SET business_id = $1 WHERE business_id = $2`,
+		`UPDATE confirmations SET business_id = $1 WHERE business_id = $2`,
+		`UPDATE business_edits SET business_id = $1 WHERE business_id = $2`,
+		`UPDATE reports SET business_id = $1 WHERE business_id = $2`,
+		`UPDATE business_revisions SET business_id = $1 WHERE business_id = $2`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, targetID, sourceID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// attributes are combined like tags
+	combine := `INSERT INTO business_attributes (business_id, attribute_id)
+		SELECT $1, attribute_id FROM business_attributes WHERE business_id = $2
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(combine, targetID, sourceID); err != nil {
+		return nil, nil, err
+	}
+
+	// owners of either listing own the survivor
+	combineOwners := `INSERT INTO owners (business_id, email, verified_at, created_at)
+		SELECT $1, email, verified_at, created_at FROM owners WHERE business_id = $2
+		ON CONFLICT (business_id, email) DO NOTHING`
+	if _, err := tx.Exec(combineOwners, targetID, sourceID); err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM owners WHERE business_id = $1`, sourceID); err != nil {
+		return nil, nil, err
+	}
+
+	// keep the source's translations only where the target has none
+	moveTranslations := `UPDATE translations AS s SET entity_id = $1
+		WHERE s.entity_type = $3 AND s.entity_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM translations AS t
+			WHERE t.entity_type = $3 AND t.entity_id = $1 AND t.field = s.field AND t.locale = s.locale
+		)`
+	if _, err := tx.Exec(moveTranslations, targetID, sourceID, models.TranslationBusiness); err != nil {
+		return nil, nil, err
+	}
+
+	// the link checker picks up the survivor's links on its next run
+	if _, err := tx.Exec(`DELETE FROM link_checks WHERE business_id = $1`, sourceID); err != nil {
+		return nil, nil, err
+	}
+
+	merged, err := lockRestaurant(tx, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = recordRevision(tx, models.Revision{
+		BusinessID: targetID,
+		Source:     models.RevisionSourceMerge,
+		Actor:      actor,
+		Summary:    fmt.Sprintf("Merged #%d (%s) into this listing", sourceID, source.Name),
+		Changes:    models.DiffRestaurants(*target, *merged),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	redirects := map[uint]uint{}
+	rows, err := tx.Query(`SELECT id FROM businesses WHERE merged_into = $1`, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint
+		if err := rows.Scan(&id); err != nil {
+			return nil, nil, err
+		}
+		redirects[id] = targetID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	restaurant, err := e.GetRestaurant(targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return restaurant, redirects, nil
+}
+
+func lockRestaurant(tx *sqlx.Tx, id uint) (*models.Restaurant, error) {
+	query := `SELECT * FROM businesses WHERE id = $1 AND deleted_at IS null FOR UPDATE`
+
+	var r models.Restaurant
+	if err := tx.QueryRowx(query, id).StructScan(&r); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
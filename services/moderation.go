@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+// submissionConditions select listings by their moderation status. They
+// follow Restaurant.SubmissionStatus, so each listing is in exactly one.
+var submissionConditions = map[string]string{
+	models.SubmissionRejected: "rejected_at IS NOT null",
+	models.SubmissionHidden:   "rejected_at IS null AND is_hidden IS TRUE",
+	models.SubmissionApproved: "rejected_at IS null AND is_hidden IS FALSE AND is_active IS TRUE",
+	models.SubmissionPending:  "rejected_at IS null AND is_hidden IS FALSE AND is_active IS FALSE",
+}
+
+// GetSubmissions lists listings with the given moderation status, oldest
+// first so the queue is worked in order.
+func (e RestaurantEntity) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	condition, ok := submissionConditions[status]
+	if !ok {
+		return nil, fmt.Errorf("unknown submission status %q", status)
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM businesses WHERE deleted_at IS null AND %s ORDER BY created_at ASC`, condition)
+
+	restaurants := []models.Restaurant{}
+	if err := e.DB.Select(&restaurants, query); err != nil {
+		return nil, err
+	}
+
+	return &restaurants, nil
+}
+
+// SearchRestaurants finds listings in any moderation status by postal code
+// or by name.
+func (e RestaurantEntity) SearchRestaurants(term string, limit int) (*[]models.Restaurant, error) {
+	query := `SELECT * FROM businesses
+		WHERE deleted_at IS null AND (
+			upper(zipcode) = upper($1)
+			OR strpos(lower(name), lower($1)) > 0
+			OR similarity(name, $1) > 0.3
+		)
+		ORDER BY upper(zipcode) = upper($1) DESC, similarity(name, $1) DESC, name ASC
+		LIMIT $2`
+
+	restaurants := []models.Restaurant{}
+	if err := e.DB.Select(&restaurants, query, term, limit); err != nil {
+		return nil, err
+	}
+
+	return &restaurants, nil
+}
+
+// HideRestaurant takes a listing off the public site on a moderator's say-so
+// and closes its open reports. It reports whether the listing was newly
+// hidden, and returns nil if the listing does not exist.
+func (e RestaurantEntity) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	restaurant, err := lockRestaurant(tx, restaurantID)
+	if err != nil || restaurant == nil {
+		return nil, false, err
+	}
+
+	hidden, err := setHidden(tx, restaurantID, true, actor, models.Revision{
+		Source:  models.RevisionSourceModeration,
+		Actor:   actor,
+		Summary: fmt.Sprintf("Hidden by %s", actor),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	update := `UPDATE reports SET status = $1, reviewed_by = $2, reviewed_at = $3 WHERE business_id = $4 AND status = $5`
+	if _, err := tx.Exec(update, models.ReportActioned, actor, time.Now(), restaurantID, models.ReportPending); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	restaurant.IsHidden = true
+	restaurant.HiddenBy = &actor
+
+	return restaurant, hidden, nil
+}
+
+// GetModerationStats counts listings by moderation status along with open
+// reports and edits waiting for review.
+func (e RestaurantEntity) GetModerationStats() (*models.ModerationStats, error) {
+	query := fmt.Sprintf(`SELECT
+			COUNT(*) FILTER (WHERE %s) AS pending,
+			COUNT(*) FILTER (WHERE %s) AS approved,
+			COUNT(*) FILTER (WHERE %s) AS hidden,
+			COUNT(*) FILTER (WHERE %s) AS rejected,
+			(SELECT COUNT(*) FROM reports WHERE status = $1) AS open_reports,
+			(SELECT COUNT(*) FROM business_edits WHERE status = $2) AS pending_edits
+		FROM businesses
+		WHERE deleted_at IS null`,
+		submissionConditions[models.SubmissionPending],
+		submissionConditions[models.SubmissionApproved],
+		submissionConditions[models.SubmissionHidden],
+		submissionConditions[models.SubmissionRejected],
+	)
+
+	var stats models.ModerationStats
+	if err := e.DB.Get(&stats, query, models.ReportPending, models.EditPending); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
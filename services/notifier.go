@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+const UnsubscribeTokenTTL = 365 * 24 * time.Hour
+
+// Submission email kinds.
+const (
+	submissionReceived = "received"
+	submissionApproved = "approved"
+	submissionRejected = "rejected"
+)
+
+var submissionSubjects = map[string]string{
+	submissionReceived: "We received your submission for %s",
+	submissionApproved: "%s is now listed on We're Open for Takeout",
+	submissionRejected: "An update on your submission for %s",
+}
+
+var (
+	submissionHTMLTemplates = map[string]*htmltemplate.Template{}
+	submissionTextTemplates = map[string]*texttemplate.Template{}
+)
+
+func init() {
+	for kind, content := range submissionHTMLContent {
+		layout := htmltemplate.Must(htmltemplate.New("layout").Parse(submissionHTMLLayout))
+		submissionHTMLTemplates[kind] = htmltemplate.Must(layout.Parse(content))
+	}
+
+	for kind, content := range submissionTextContent {
+		layout := texttemplate.Must(texttemplate.New("layout").Parse(submissionTextLayout))
+		submissionTextTemplates[kind] = texttemplate.Must(layout.Parse(content))
+	}
+}
+
+type submissionEmailData struct {
+	Restaurant     models.Restaurant
+	Reason         string
+	UnsubscribeURL string
+}
+
+// SubmissionNotifier emails submitters as their listing moves through
+// moderation. Addresses that have unsubscribed are skipped.
+type SubmissionNotifier struct {
+	Mailer       Mailer
+	Unsubscribes UnsubscribeEntityInterface
+	Signer       TokenSigner
+	BaseURL      string
+}
+
+func (n SubmissionNotifier) SubmissionReceived(restaurant models.Restaurant) error {
+	return n.notify(submissionReceived, restaurant, "")
+}
+
+func (n SubmissionNotifier) SubmissionApproved(restaurant models.Restaurant) error {
+	return n.notify(submissionApproved, restaurant, "")
+}
+
+// SubmissionRejected explains a rejection using the listing's recorded
+// reason. Spammers don't get told their submission was spotted.
+func (n SubmissionNotifier) SubmissionRejected(restaurant models.Restaurant) error {
+	if restaurant.RejectionReason == nil || *restaurant.RejectionReason == models.RejectionSpam {
+		return nil
+	}
+
+	return n.notify(submissionRejected, restaurant, models.RejectionLabel(*restaurant.RejectionReason))
+}
+
+func (n SubmissionNotifier) notify(kind string, restaurant models.Restaurant, reason string) error {
+	email := strings.ToLower(strings.TrimSpace(restaurant.Email))
+	if n.Mailer == nil || email == "" {
+		return nil
+	}
+
+	unsubscribed, err := n.Unsubscribes.IsUnsubscribed(email)
+	if err != nil || unsubscribed {
+		return err
+	}
+
+	token, err := n.Signer.Sign(TokenClaims{
+		Purpose:   TokenPurposeUnsubscribe,
+		Email:     email,
+		ExpiresAt: time.Now().Add(UnsubscribeTokenTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	data := submissionEmailData{
+		Restaurant:     restaurant,
+		Reason:         reason,
+		UnsubscribeURL: fmt.Sprintf("%s/notifications/unsubscribe?token=%s", strings.TrimRight(n.BaseURL, "/"), url.QueryEscape(token)),
+	}
+
+	var text, html bytes.Buffer
+	if err := submissionTextTemplates[kind].Execute(&text, data); err != nil {
+		return err
+	}
+	if err := submissionHTMLTemplates[kind].Execute(&html, data); err != nil {
+		return err
+	}
+
+	return n.Mailer.Send(models.Email{
+		To:      email,
+		Subject: fmt.Sprintf(submissionSubjects[kind], restaurant.Name),
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      fmt.Sprintf("<%s>", data.UnsubscribeURL),
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+const submissionHTMLLayout = `<!DOCTYPE html>
+<html>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #222222; line-height: 1.5;">
+{{template "content" .}}
+<p style="font-size: 12px; color: #888888;">
+You're receiving this because this address was used to submit a listing to We're Open for Takeout.
+<a href="{{.UnsubscribeURL}}">Unsubscribe</a>
+</p>
+</body>
+</html>
+`
+
+const submissionTextLayout = `{{template "content" .}}
+--
+You're receiving this because this address was used to submit a listing to We're Open for Takeout.
+Unsubscribe: {{.UnsubscribeURL}}
+`
+
+var submissionHTMLContent = map[string]string{
+	submissionReceived: `{{define "content"}}<p>Thanks for submitting <strong>{{.Restaurant.Name}}</strong>!</p>
+<p>Our volunteers review every listing before it goes live. We'll email you again once it has been reviewed.</p>{{end}}`,
+	submissionApproved: `{{define "content"}}<p>Good news: <strong>{{.Restaurant.Name}}</strong> has been approved and is now listed.</p>
+<p>Thanks for helping people find local takeout.</p>{{end}}`,
+	submissionRejected: `{{define "content"}}<p>We weren't able to list <strong>{{.Restaurant.Name}}</strong>.</p>
+{{if .Reason}}<p>Reason: {{.Reason}}</p>{{end}}
+<p>If you think this was a mistake, you're welcome to submit it again with more details.</p>{{end}}`,
+}
+
+var submissionTextContent = map[string]string{
+	submissionReceived: `{{define "content"}}Thanks for submitting {{.Restaurant.Name}}!
+
+Our volunteers review every listing before it goes live. We'll email you again once it has been reviewed.
+{{end}}`,
+	submissionApproved: `{{define "content"}}Good news: {{.Restaurant.Name}} has been approved and is now listed.
+
+Thanks for helping people find local takeout.
+{{end}}`,
+	submissionRejected: `{{define "content"}}We weren't able to list {{.Restaurant.Name}}.
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}
+If you think this was a mistake, you're welcome to submit it again with more details.
+{{end}}`,
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+type OwnerEntity struct {
+	DB *sqlx.DB
+}
+
+// CreateOwner records a verified owner for a listing and marks the listing as
+// verified. Claiming the same listing twice with one address is a no-op.
+func (e OwnerEntity) CreateOwner(restaurantID uint, email string) (*models.Owner, error) {
+	now := time.Now()
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO owners (business_id, email, verified_at, created_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (business_id, email) DO UPDATE SET verified_at = owners.verified_at
+		RETURNING *`
+
+	var owner models.Owner
+	if err := tx.QueryRowx(insert, restaurantID, email, now).StructScan(&owner); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`UPDATE businesses SET is_verified = TRUE, updated_at = $1 WHERE id = $2 AND is_verified IS FALSE`, now, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		err = recordRevision(tx, models.Revision{
+			BusinessID: restaurantID,
+			Source:     models.RevisionSourceClaim,
+			Actor:      email,
+			Summary:    fmt.Sprintf("Claimed by %s", email),
+			Changes: models.RevisionChanges{
+				"verified": {Before: false, After: true},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &owner, nil
+}
+
+// GetOwnedRestaurantIDs returns the live listings an email address has been
+// verified as owning.
+func (e OwnerEntity) GetOwnedRestaurantIDs(email string) ([]uint, error) {
+	query := `SELECT owners.business_id FROM owners
+		JOIN businesses ON businesses.id = owners.business_id
+		WHERE lower(owners.email) = $1 AND businesses.deleted_at IS null
+		ORDER BY owners.business_id`
+
+	ids := []uint{}
+	if err := e.DB.Select(&ids, query, strings.ToLower(strings.TrimSpace(email))); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type ownerContextKey struct{}
+
+// OwnerAuth authenticates owner requests with the session tokens issued after
+// a magic-link login. Sessions are scoped to the listings owned at login.
+type OwnerAuth struct {
+	Signer TokenSigner
+}
+
+// Authenticate returns the session for a request's bearer token, or nil if
+// the token is missing, invalid or expired.
+func (a OwnerAuth) Authenticate(r *http.Request) *TokenClaims {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+
+	claims, err := a.Signer.Verify(token, TokenPurposeSession)
+	if err != nil {
+		return nil
+	}
+
+	return claims
+}
+
+func (a OwnerAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := a.Authenticate(r)
+		if session == nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), ownerContextKey{}, session)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// OwnerFromContext returns the session of the owner making the request.
+func OwnerFromContext(ctx context.Context) *TokenClaims {
+	session, _ := ctx.Value(ownerContextKey{}).(*TokenClaims)
+	return session
+}
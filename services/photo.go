@@ -0,0 +1,167 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+// PhotoPreviewTTL is how long moderators can view a pending photo from its
+// Slack message.
+const PhotoPreviewTTL = 7 * 24 * time.Hour
+
+type PhotoEntity struct {
+	DB     *sqlx.DB
+	Signer TokenSigner
+}
+
+func (e PhotoEntity) CreatePhoto(newPhoto models.Photo) (*uint, error) {
+	now := time.Now()
+
+	insert := `INSERT INTO photos (
+		business_id,
+		storage_key,
+		thumbnail_key,
+		url,
+		thumbnail_url,
+		content_type,
+		width,
+		height,
+		is_approved,
+		created_at,
+		updated_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, FALSE, $9, $10) RETURNING id`
+
+	var newID uint
+
+	err := e.DB.QueryRow(
+		insert,
+		newPhoto.BusinessID,
+		newPhoto.StorageKey,
+		newPhoto.ThumbnailKey,
+		newPhoto.URL,
+		newPhoto.ThumbnailURL,
+		newPhoto.ContentType,
+		newPhoto.Width,
+		newPhoto.Height,
+		now,
+		now,
+	).Scan(&newID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	newPhoto.ID = newID
+	e.CreatePhotoMsg(newPhoto, strconv.Itoa(int(newID)))
+
+	return &newID, nil
+}
+
+// ApprovePhoto publishes a pending photo. It reports whether this call
+// approved it, so a second click leaves the first reviewer on record.
+func (e PhotoEntity) ApprovePhoto(photoID uint, reviewer string) (*models.Photo, bool, error) {
+	now := time.Now()
+
+	update := `UPDATE photos SET is_approved = TRUE, approved_at = $1, approved_by = $2, updated_at = $1 WHERE id = $3 AND is_approved IS FALSE`
+	result, err := e.DB.Exec(update, now, reviewer, photoID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	approved, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	query := `SELECT * FROM photos WHERE id = $1`
+	var photo models.Photo
+	if err := e.DB.QueryRowx(query, photoID).StructScan(&photo); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return &photo, approved > 0, nil
+}
+
+// GetPhotoByKey finds the photo a stored original or thumbnail belongs to.
+func (e PhotoEntity) GetPhotoByKey(key string) (*models.Photo, error) {
+	query := `SELECT * FROM photos WHERE (storage_key = $1 OR thumbnail_key = $1) AND deleted_at IS null`
+
+	var photo models.Photo
+	if err := e.DB.QueryRowx(query, key).StructScan(&photo); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &photo, nil
+}
+
+func (e PhotoEntity) CreatePhotoMsg(photo models.Photo, photoID string) {
+	// pending photos are only served with a preview token
+	token, err := e.Signer.Sign(TokenClaims{
+		Purpose:      TokenPurposePhotoPreview,
+		RestaurantID: photo.BusinessID,
+		PhotoID:      photo.ID,
+		ExpiresAt:    time.Now().Add(PhotoPreviewTTL).Unix(),
+	})
+	if err == nil {
+		preview := "?preview=" + url.QueryEscape(token)
+		photo.URL += preview
+		photo.ThumbnailURL += preview
+	}
+
+	msg := models.SlackMsg{}
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "section",
+		Text: &models.SlackMsgText{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("A new photo has been uploaded for restaurant #%d:\n<%s|View full size>", photo.BusinessID, photo.URL),
+		},
+	})
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type:     "image",
+		ImageURL: photo.ThumbnailURL,
+		AltText:  fmt.Sprintf("Photo %s", photoID),
+	})
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "actions",
+		Elements: &[]models.SlackMsgAction{
+			{
+				ActionID: models.SlackActionApprovePhoto,
+				Type:     "button",
+				Style:    "primary",
+				Value:    photoID,
+				Text: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Approve Photo",
+				},
+			},
+		},
+	})
+
+	postSlackMsg(msg)
+}
+
+func (e PhotoEntity) getApprovedPhotos(restaurantID uint) ([]models.Photo, error) {
+	photos := []models.Photo{}
+
+	query := `SELECT * FROM photos WHERE business_id = $1 AND is_approved IS TRUE AND deleted_at IS null ORDER BY created_at ASC`
+	if err := e.DB.Select(&photos, query, restaurantID); err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
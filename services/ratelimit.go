@@ -0,0 +1,96 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxRateLimitKeys bounds memory use; expired windows are pruned once the
+// limiter tracks this many keys.
+const maxRateLimitKeys = 10000
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimiter allows up to Limit events per key in each fixed Window. It is
+// kept in memory, so limits reset when the server restarts.
+type RateLimiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		Limit:   limit,
+		Window:  window,
+		windows: map[string]*rateWindow{},
+	}
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+func (l *RateLimiter) Allow(key string) bool {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window, ok := l.windows[key]
+	if !ok || now.Sub(window.start) >= l.Window {
+		if len(l.windows) >= maxRateLimitKeys {
+			l.prune(now)
+		}
+
+		window = &rateWindow{start: now}
+		l.windows[key] = window
+	}
+
+	if window.count >= l.Limit {
+		return false
+	}
+
+	window.count++
+	return true
+}
+
+func (l *RateLimiter) prune(now time.Time) {
+	for key, window := range l.windows {
+		if now.Sub(window.start) >= l.Window {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// ClientIP returns the address a request came from. Behind a proxy, the
+// server's ProxyHeaders handler has already applied X-Forwarded-For.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// ClientHasher turns client addresses into stable identifiers. The hash is
+// keyed with a server secret, since a plain hash of an IPv4 address can be
+// reversed by trying every address.
+type ClientHasher struct {
+	Secret []byte
+}
+
+// Hash returns a stable identifier for a client without storing its address.
+func (h ClientHasher) Hash(value string) string {
+	mac := hmac.New(sha256.New, h.Secret)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
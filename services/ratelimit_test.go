@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	limiter := NewRateLimiter(2, 50*time.Millisecond)
+
+	assert.True(limiter.Allow("a"))
+	assert.True(limiter.Allow("a"))
+	assert.False(limiter.Allow("a"))
+	assert.True(limiter.Allow("b"))
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.True(limiter.Allow("a"))
+}
+
+func TestClientHasherIsKeyed(t *testing.T) {
+	assert := assert.New(t)
+
+	a := ClientHasher{Secret: []byte("one")}
+	b := ClientHasher{Secret: []byte("two")}
+
+	assert.Equal(a.Hash("203.0.113.9"), a.Hash("203.0.113.9"))
+	assert.NotEqual(a.Hash("203.0.113.9"), a.Hash("203.0.113.10"))
+	assert.NotEqual(a.Hash("203.0.113.9"), b.Hash("203.0.113.9"))
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+const DefaultReportHideThreshold = 3
+
+type ReportEntity struct {
+	DB            *sqlx.DB
+	HideThreshold int
+}
+
+// CreateReport stores a community report and hides the listing once enough
+// distinct people have reported it. Every report is forwarded to Slack.
+func (e ReportEntity) CreateReport(newReport models.Report, restaurant models.Restaurant) (*models.Report, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	insert := `INSERT INTO reports (business_id, reason, note, reporter_hash, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING *`
+
+	var report models.Report
+	err = tx.QueryRowx(
+		insert,
+		newReport.BusinessID,
+		newReport.Reason,
+		newReport.Note,
+		newReport.ReporterHash,
+		models.ReportPending,
+		time.Now(),
+	).StructScan(&report)
+	if err != nil {
+		return nil, err
+	}
+
+	reporters, err := countReporters(tx, report.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden := false
+	if reporters >= e.threshold() {
+		hidden, err = setHidden(tx, report.BusinessID, true, models.HiddenByReports, models.Revision{
+			Source:  models.RevisionSourceReports,
+			Actor:   models.HiddenByReports,
+			Summary: fmt.Sprintf("Hidden after reports from %d people", reporters),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	e.CreateReportMsg(report, restaurant, reporters, hidden)
+
+	return &report, nil
+}
+
+// HideReported hides a reported listing on a moderator's say-so and closes
+// its open reports. Reports that were already reviewed are returned as is.
+func (e ReportEntity) HideReported(reportID uint, reviewer string) (*models.Report, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := lockReport(tx, reportID)
+	if err != nil || report == nil || report.Status != models.ReportPending {
+		return report, err
+	}
+
+	_, err = setHidden(tx, report.BusinessID, true, reviewer, models.Revision{
+		Source:  models.RevisionSourceModeration,
+		Actor:   reviewer,
+		Summary: fmt.Sprintf("Hidden by %s after report #%d (%s)", reviewer, report.ID, report.Reason),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the listing is hidden, so the other open reports are dealt with too
+	now := time.Now()
+	update := `UPDATE reports SET status = $1, reviewed_by = $2, reviewed_at = $3 WHERE business_id = $4 AND status = $5`
+	if _, err := tx.Exec(update, models.ReportActioned, reviewer, now, report.BusinessID, models.ReportPending); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	report.Status = models.ReportActioned
+	report.ReviewedBy = &reviewer
+	report.ReviewedAt = &now
+
+	return report, nil
+}
+
+// DismissReport discards a report. If the listing was hidden automatically and
+// no longer has enough reports, it is shown again.
+func (e ReportEntity) DismissReport(reportID uint, reviewer string) (*models.Report, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report, err := lockReport(tx, reportID)
+	if err != nil || report == nil || report.Status != models.ReportPending {
+		return report, err
+	}
+
+	now := time.Now()
+	update := `UPDATE reports SET status = $1, reviewed_by = $2, reviewed_at = $3 WHERE id = $4`
+	if _, err := tx.Exec(update, models.ReportDismissed, reviewer, now, report.ID); err != nil {
+		return nil, err
+	}
+
+	reporters, err := countReporters(tx, report.BusinessID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reporters < e.threshold() {
+		var hiddenBy sql.NullString
+		if err := tx.Get(&hiddenBy, `SELECT hidden_by FROM businesses WHERE id = $1 FOR UPDATE`, report.BusinessID); err != nil {
+			return nil, err
+		}
+
+		if hiddenBy.String == models.HiddenByReports {
+			_, err = setHidden(tx, report.BusinessID, false, "", models.Revision{
+				Source:  models.RevisionSourceModeration,
+				Actor:   reviewer,
+				Summary: fmt.Sprintf("Shown again after %s dismissed report #%d", reviewer, report.ID),
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	report.Status = models.ReportDismissed
+	report.ReviewedBy = &reviewer
+	report.ReviewedAt = &now
+
+	return report, nil
+}
+
+func (e ReportEntity) threshold() int {
+	if e.HideThreshold <= 0 {
+		return DefaultReportHideThreshold
+	}
+
+	return e.HideThreshold
+}
+
+func lockReport(tx *sqlx.Tx, id uint) (*models.Report, error) {
+	var report models.Report
+	if err := tx.QueryRowx(`SELECT * FROM reports WHERE id = $1 FOR UPDATE`, id).StructScan(&report); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// countReporters counts the distinct people with open reports on a listing.
+func countReporters(tx *sqlx.Tx, restaurantID uint) (int, error) {
+	var reporters int
+	query := `SELECT COUNT(DISTINCT reporter_hash) FROM reports WHERE business_id = $1 AND status = $2`
+	err := tx.Get(&reporters, query, restaurantID, models.ReportPending)
+	return reporters, err
+}
+
+// setHidden hides or shows a listing and records the change. It reports
+// whether the listing's visibility changed. A moderator hiding a listing that
+// reports already hid takes ownership of it, so dismissing later reports
+// won't show it again.
+func setHidden(tx *sqlx.Tx, restaurantID uint, hidden bool, hiddenBy string, revision models.Revision) (bool, error) {
+	var isHidden bool
+	if err := tx.Get(&isHidden, `SELECT is_hidden FROM businesses WHERE id = $1 FOR UPDATE`, restaurantID); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+
+	if isHidden == hidden {
+		if hidden && hiddenBy != models.HiddenByReports {
+			_, err := tx.Exec(`UPDATE businesses SET hidden_by = $1, updated_at = $2 WHERE id = $3`, hiddenBy, now, restaurantID)
+			return false, err
+		}
+		return false, nil
+	}
+
+	update := `UPDATE businesses SET is_hidden = $1, hidden_by = NULLIF($2, ''), updated_at = $3 WHERE id = $4`
+	if _, err := tx.Exec(update, hidden, hiddenBy, now, restaurantID); err != nil {
+		return false, err
+	}
+
+	revision.BusinessID = restaurantID
+	revision.Changes = models.RevisionChanges{
+		"hidden": {Before: isHidden, After: hidden},
+	}
+
+	return true, recordRevision(tx, revision)
+}
+
+// slackEscaper escapes the characters Slack treats as markup in mrkdwn, so
+// free text can't add mentions or links.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escapeSlack(text string) string {
+	return slackEscaper.Replace(text)
+}
+
+func (e ReportEntity) CreateReportMsg(report models.Report, restaurant models.Restaurant, reporters int, hidden bool) {
+	msg := models.SlackMsg{}
+
+	text := fmt.Sprintf(
+		"*%s* (#%d) has been reported as *%s*\n%s\n\nPeople reporting it: %d of %d",
+		restaurant.Name,
+		restaurant.ID,
+		report.Reason,
+		escapeSlack(report.Note),
+		reporters,
+		e.threshold(),
+	)
+	if hidden {
+		text += "\n:no_entry: The listing has been hidden automatically"
+	}
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "section",
+		Text: &models.SlackMsgText{
+			Type: "mrkdwn",
+			Text: text,
+		},
+	})
+
+	reportID := strconv.Itoa(int(report.ID))
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "actions",
+		Elements: &[]models.SlackMsgAction{
+			{
+				ActionID: models.SlackActionHideReport,
+				Type:     "button",
+				Style:    "danger",
+				Value:    reportID,
+				Text: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Hide",
+				},
+			},
+			{
+				ActionID: models.SlackActionDismissReport,
+				Type:     "button",
+				Value:    reportID,
+				Text: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Dismiss",
+				},
+			},
+		},
+	})
+
+	postSlackMsg(msg)
+}
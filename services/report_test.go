@@ -0,0 +1,15 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeSlack(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("closed &amp; gone", escapeSlack("closed & gone"))
+	assert.Equal("&lt;!channel&gt; &lt;https://evil.example|click&gt;", escapeSlack("<!channel> <https://evil.example|click>"))
+	assert.Equal("plain note", escapeSlack("plain note"))
+}
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/theproducer/openfortakeout_api/models"
 )
 
@@ -42,12 +44,48 @@ func (e RestaurantEntity) CreateRestaurant(newRestaurant models.Restaurant) (*ui
 		is_active,
 		created_at,
 		updated_at,
-		tags
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, ST_POINT($13, $14), $15, $16, $17, $18, $19, $20) RETURNING id`
+		tags,
+		ordering_channels,
+		takeout,
+		curbside,
+		delivery,
+		drive_through,
+		country,
+		phone_e164,
+		phone_extension,
+		duplicate_candidates,
+		payment_methods
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, ST_POINT($13, $14), $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30) RETURNING id`
+
+	newRestaurant.OrderingChannels.Sort()
+
+	tags, err := TagEntity{DB: e.DB}.NormalizeTags(newRestaurant.Tags)
+	if err != nil {
+		return nil, err
+	}
+	newRestaurant.Tags = tags
+
+	duplicates, err := e.FindDuplicates(newRestaurant)
+	if err != nil {
+		return nil, err
+	}
+
+	newRestaurant.DuplicateIDs = pq.Int64Array{}
+	for _, duplicate := range duplicates {
+		newRestaurant.DuplicateIDs = append(newRestaurant.DuplicateIDs, int64(duplicate.ID))
+	}
+
+	// the listing, its attributes and translations are saved together so a
+	// failed submission can be retried without leaving a duplicate behind
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
 	var newID uint
 
-	err := e.DB.QueryRow(
+	err = tx.QueryRow(
 		insert,
 		newRestaurant.Name,
 		newRestaurant.Type,
@@ -69,54 +107,283 @@ func (e RestaurantEntity) CreateRestaurant(newRestaurant models.Restaurant) (*ui
 		now,
 		now,
 		newRestaurant.Tags,
+		newRestaurant.OrderingChannels,
+		newRestaurant.Takeout,
+		newRestaurant.Curbside,
+		newRestaurant.Delivery,
+		newRestaurant.DriveThrough,
+		newRestaurant.Country,
+		newRestaurant.PhoneE164,
+		newRestaurant.PhoneExtension,
+		newRestaurant.DuplicateIDs,
+		newRestaurant.PaymentMethods,
 	).Scan(&newID)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if len(newRestaurant.Attributes) > 0 {
+		if err := setBusinessAttributes(tx, newID, newRestaurant.Attributes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := setTranslations(tx, models.TranslationBusiness, newID, newRestaurant.Translations); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	if &newID != nil {
 		newIDStr := strconv.Itoa(int(newID))
-		e.CreateRestaurantMsg(newRestaurant, newIDStr)
+		e.CreateRestaurantMsg(newRestaurant, newIDStr, duplicates)
 	}
 
 	return &newID, nil
 }
 
-func (e RestaurantEntity) ApproveRestaurant(restaurantID uint) (*models.Restaurant, error) {
-	now := time.Now()
+// duplicateNameThreshold is the trigram similarity above which a nearby
+// listing's name counts as a possible duplicate.
+const duplicateNameThreshold = 0.3
+
+// FindDuplicates looks for existing listings that share the submission's phone
+// number, or that have a similar name within about 100 m of it.
+func (e RestaurantEntity) FindDuplicates(restaurant models.Restaurant) ([]models.DuplicateCandidate, error) {
+	duplicates := []models.DuplicateCandidate{}
+
+	isGeoLocated := restaurant.LatLng.Lat != 0.00 && restaurant.LatLng.Lng != 0.00
+
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// name % $2 can use the trigram index, similarity() can't, so the
+	// threshold is set for this transaction and similarity() only ranks.
+	_, err = tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.similarity_threshold = %v", duplicateNameThreshold))
+	if err != nil {
+		return nil, err
+	}
 
-	update := `UPDATE businesses SET is_active = TRUE, updated_at = $1 WHERE id = $2`
-	err := e.DB.QueryRowx(update, now, restaurantID).Err()
+	query := `SELECT
+			id,
+			name,
+			($1 <> '' AND phone_e164 = $1) AS phone_match,
+			similarity(name, $2) AS similarity,
+			CASE WHEN $5 THEN ST_Distance(location, ST_POINT($3, $4)::geography) END AS distance
+		FROM businesses
+		WHERE deleted_at IS null AND rejected_at IS null AND (
+			($1 <> '' AND phone_e164 = $1)
+			OR ($5 AND ST_DWithin(location, ST_POINT($3, $4)::geography, 100) AND name % $2)
+		)
+		ORDER BY phone_match DESC, similarity DESC
+		LIMIT 5`
+
+	err = tx.Select(
+		&duplicates,
+		query,
+		restaurant.PhoneE164,
+		restaurant.Name,
+		restaurant.LatLng.Lng,
+		restaurant.LatLng.Lat,
+		isGeoLocated,
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return e.GetRestaurant(restaurantID)
+	return duplicates, tx.Commit()
 }
 
-func (e RestaurantEntity) GetRestaurants(lat *float64, lng *float64) (*[]models.Restaurant, error) {
-	var rows *sqlx.Rows
-	var err error
+// ApproveRestaurant publishes a submission and records who approved it. A
+// rejected submission stays rejected unless overrule is set. The listing is
+// locked first, so double clicks and concurrent moderators approve it once;
+// the bool reports whether this call approved it.
+func (e RestaurantEntity) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
 
-	fmt.Printf("%v, %v\n", *lat, *lng)
+	restaurant, err := lockRestaurant(tx, restaurantID)
+	if err != nil || restaurant == nil {
+		return nil, false, err
+	}
+
+	if restaurant.IsActive && restaurant.RejectedAt == nil {
+		return restaurant, false, nil
+	}
 
-	if *lat != 0.00 && *lng != 0.00 {
+	if restaurant.RejectedAt != nil && !overrule {
+		return restaurant, false, nil
+	}
+
+	now := time.Now()
+
+	update := `UPDATE businesses SET
+		is_active = TRUE,
+		approved_at = $1,
+		approved_by = $2,
+		rejected_at = null,
+		rejected_by = null,
+		rejection_reason = null,
+		updated_at = $1
+	WHERE id = $3`
+	if _, err := tx.Exec(update, now, reviewer, restaurantID); err != nil {
+		return nil, false, err
+	}
+
+	err = recordRevision(tx, models.Revision{
+		BusinessID: restaurantID,
+		Source:     models.RevisionSourceModeration,
+		Actor:      reviewer,
+		Summary:    fmt.Sprintf("Approved by %s", reviewer),
+		Changes: models.RevisionChanges{
+			"active": {Before: false, After: true},
+		},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	approved, err := e.GetRestaurant(restaurantID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return approved, true, nil
+}
+
+// RejectRestaurant declines a submission, recording who rejected it and why.
+// Rejected listings are never shown publicly. An approved listing stays live
+// unless overrule is set. The bool reports whether this call rejected it, as
+// opposed to it having been decided already.
+func (e RestaurantEntity) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	restaurant, err := lockRestaurant(tx, restaurantID)
+	if err != nil || restaurant == nil {
+		return nil, false, err
+	}
+
+	if restaurant.RejectedAt != nil {
+		return restaurant, false, nil
+	}
+
+	if (restaurant.ApprovedAt != nil || restaurant.IsActive) && !overrule {
+		return restaurant, false, nil
+	}
+
+	now := time.Now()
+
+	update := `UPDATE businesses SET is_active = FALSE, rejected_at = $1, rejected_by = $2, rejection_reason = $3, updated_at = $1 WHERE id = $4`
+	if _, err := tx.Exec(update, now, reviewer, reason, restaurantID); err != nil {
+		return nil, false, err
+	}
+
+	err = recordRevision(tx, models.Revision{
+		BusinessID: restaurantID,
+		Source:     models.RevisionSourceModeration,
+		Actor:      reviewer,
+		Summary:    fmt.Sprintf("Rejected by %s: %s", reviewer, models.RejectionLabel(reason)),
+		Changes: models.RevisionChanges{
+			"rejection_reason": {Before: nil, After: reason},
+		},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+
+	restaurant.IsActive = false
+	restaurant.RejectedAt = &now
+	restaurant.RejectedBy = &reviewer
+	restaurant.RejectionReason = &reason
+
+	return restaurant, true, nil
+}
+
+// listingConditions builds the WHERE clause for public listing searches.
+func listingConditions(lat float64, lng float64, filters models.RestaurantFilters) ([]string, []interface{}) {
+	conditions := []string{"deleted_at IS null", "rejected_at IS null", "is_active IS TRUE", "is_hidden IS FALSE"}
+	args := []interface{}{}
+
+	if lat != 0.00 && lng != 0.00 {
 		// default search within 30 mi
-		query := `SELECT *, ST_AsText(location) as location FROM businesses WHERE ST_DWithin(ST_POINT($1, $2)::geography, location, 48280.32) AND deleted_at IS null AND is_active IS TRUE ORDER BY name ASC`
-		rows, err = e.DB.Queryx(query, lng, lat)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		query := `SELECT *, ST_AsText(location) as location FROM businesses WHERE deleted_at IS null AND is_active IS TRUE ORDER BY name ASC`
-		rows, err = e.DB.Queryx(query)
-		if err != nil {
-			return nil, err
+		args = append(args, lng, lat)
+		conditions = append(conditions, "ST_DWithin(ST_POINT($1, $2)::geography, location, 48280.32)")
+	}
+
+	if len(filters.Channels) > 0 {
+		channelConditions := []string{}
+		for _, channel := range filters.Channels {
+			containment, _ := json.Marshal([]map[string]string{{"type": channel}})
+			args = append(args, string(containment))
+			channelConditions = append(channelConditions, fmt.Sprintf("ordering_channels @> $%d::jsonb", len(args)))
 		}
+		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(channelConditions, " OR ")))
+	}
+
+	// service modes map directly onto their boolean columns
+	for _, mode := range filters.ServiceModes {
+		conditions = append(conditions, fmt.Sprintf("%s IS TRUE", pq.QuoteIdentifier(mode)))
 	}
 
+	// a listing matches if it accepts any of the requested payment methods
+	if len(filters.PaymentMethods) > 0 {
+		args = append(args, pq.StringArray(filters.PaymentMethods))
+		conditions = append(conditions, fmt.Sprintf("payment_methods && $%d", len(args)))
+	}
+
+	for _, attribute := range filters.Attributes {
+		args = append(args, attribute)
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM business_attributes JOIN attributes ON attributes.id = business_attributes.attribute_id WHERE business_attributes.business_id = businesses.id AND attributes.slug = $%d)",
+			len(args),
+		))
+	}
+
+	if filters.OpenNow {
+		conditions = append(conditions, fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM status_updates WHERE business_id = businesses.id AND type = '%s' AND %s)",
+			models.StatusTemporarilyClosed,
+			activeStatusCondition,
+		))
+	}
+
+	return conditions, args
+}
+
+func (e RestaurantEntity) GetRestaurants(lat *float64, lng *float64, filters models.RestaurantFilters) (*[]models.Restaurant, error) {
+	fmt.Printf("%v, %v\n", *lat, *lng)
+
+	conditions, args := listingConditions(*lat, *lng, filters)
+
+	query := fmt.Sprintf(`SELECT *, ST_AsText(location) as location FROM businesses WHERE %s ORDER BY name ASC`, strings.Join(conditions, " AND "))
+	rows, err := e.DB.Queryx(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	restaurants := []models.Restaurant{}
+	now := time.Now()
 
 	for rows.Next() {
 		var r models.Restaurant
@@ -134,10 +401,26 @@ func (e RestaurantEntity) GetRestaurants(lat *float64, lng *float64) (*[]models.
 
 		r.LatLng.Lat = storedLat
 		r.LatLng.Lng = storedLng
+		r.PhoneDisplay = models.FormatPhone(r.PhoneE164, r.PhoneExtension)
+		r.Freshness = r.ComputeFreshness(now)
 
 		restaurants = append(restaurants, r)
 	}
 
+	if filters.SortByFreshness {
+		sort.SliceStable(restaurants, func(i, j int) bool {
+			return restaurants[i].Freshness > restaurants[j].Freshness
+		})
+	}
+
+	if err := e.attachStatuses(restaurants); err != nil {
+		return nil, err
+	}
+
+	if err := e.attachAttributes(restaurants); err != nil {
+		return nil, err
+	}
+
 	return &restaurants, nil
 }
 
@@ -153,10 +436,69 @@ func (e RestaurantEntity) GetRestaurant(id uint) (*models.Restaurant, error) {
 		return nil, err
 	}
 
-	return &r, nil
+	r.PhoneDisplay = models.FormatPhone(r.PhoneE164, r.PhoneExtension)
+	r.Freshness = r.ComputeFreshness(time.Now())
+
+	photos, err := PhotoEntity{DB: e.DB}.getApprovedPhotos(id)
+	if err != nil {
+		return nil, err
+	}
+	r.Photos = photos
+
+	restaurants := []models.Restaurant{r}
+	if err := e.attachStatuses(restaurants); err != nil {
+		return nil, err
+	}
+
+	if err := e.attachAttributes(restaurants); err != nil {
+		return nil, err
+	}
+
+	return &restaurants[0], nil
+}
+
+func (e RestaurantEntity) attachStatuses(restaurants []models.Restaurant) error {
+	ids := make([]uint, len(restaurants))
+	for i, r := range restaurants {
+		ids[i] = r.ID
+	}
+
+	statuses, err := StatusUpdateEntity{DB: e.DB}.getActiveStatuses(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range restaurants {
+		if status, ok := statuses[restaurants[i].ID]; ok {
+			restaurants[i].Status = &status
+		}
+	}
+
+	return nil
 }
 
-func (e RestaurantEntity) CreateRestaurantMsg(restaurant models.Restaurant, restID string) {
+func (e RestaurantEntity) attachAttributes(restaurants []models.Restaurant) error {
+	ids := make([]uint, len(restaurants))
+	for i, r := range restaurants {
+		ids[i] = r.ID
+	}
+
+	attributes, err := AttributeEntity{DB: e.DB}.getBusinessAttributes(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range restaurants {
+		restaurants[i].Attributes = attributes[restaurants[i].ID]
+		if restaurants[i].Attributes == nil {
+			restaurants[i].Attributes = []string{}
+		}
+	}
+
+	return nil
+}
+
+func (e RestaurantEntity) CreateRestaurantMsg(restaurant models.Restaurant, restID string, duplicates []models.DuplicateCandidate) {
 	msg := models.SlackMsg{}
 
 	introSection := models.SlackMsgBlock{
@@ -164,26 +506,55 @@ func (e RestaurantEntity) CreateRestaurantMsg(restaurant models.Restaurant, rest
 		Text: &models.SlackMsgText{
 			Type: "mrkdwn",
 			Text: fmt.Sprintf(
-				"A new entry has been submitted:\n*%s*\n%s %s\n%s, %s %s\n\nEmail: %s - Phone: %s",
+				"A new entry has been submitted:\n*%s*\n%s %s\n%s, %s %s %s\n\nEmail: %s - Phone: %s",
 				restaurant.Name,
 				restaurant.Address,
 				restaurant.Address2,
 				restaurant.City,
 				restaurant.State,
 				restaurant.Zipcode,
+				restaurant.Country,
 				restaurant.Email,
-				restaurant.Phone,
+				restaurant.PhoneDisplay,
 			),
 		},
 	}
 	msg.Blocks = append(msg.Blocks, introSection)
 
+	if len(duplicates) > 0 {
+		matches := []string{}
+		for _, duplicate := range duplicates {
+			reason := fmt.Sprintf("%.0f%% name match", duplicate.Similarity*100)
+			if duplicate.PhoneMatch {
+				reason = "same phone"
+			}
+			if duplicate.DistanceMeters != nil {
+				reason = fmt.Sprintf("%s, %.0f m away", reason, *duplicate.DistanceMeters)
+			}
+			matches = append(matches, fmt.Sprintf("#%d *%s* (%s)", duplicate.ID, duplicate.Name, reason))
+		}
+
+		duplicatesSection := models.SlackMsgBlock{
+			Type: "section",
+			Text: &models.SlackMsgText{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf(":warning: Possible duplicate of %s", strings.Join(matches, ", ")),
+			},
+		}
+		msg.Blocks = append(msg.Blocks, duplicatesSection)
+	}
+
 	isGeoLocated := false
 
 	if restaurant.LatLng.Lat != 0.00 && restaurant.LatLng.Lng != 0.00 {
 		isGeoLocated = true
 	}
 
+	channels := []string{}
+	for _, channel := range restaurant.OrderingChannels {
+		channels = append(channels, fmt.Sprintf("<%s|%s>", channel.URL, channel.Type))
+	}
+
 	infoSection := models.SlackMsgBlock{
 		Type: "section",
 		Fields: &[]models.SlackMsgText{
@@ -199,6 +570,22 @@ func (e RestaurantEntity) CreateRestaurantMsg(restaurant models.Restaurant, rest
 				Type: "mrkdwn",
 				Text: fmt.Sprintf("*URL:*\n%s", restaurant.URL),
 			},
+			{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*Ordering:*\n%s", strings.Join(channels, ", ")),
+			},
+			{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*Service:*\n%s", strings.Join(restaurant.ServiceModes.List(), ", ")),
+			},
+			{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*Attributes:*\n%s", strings.Join(restaurant.Attributes, ", ")),
+			},
+			{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("*Payment:*\n%s", strings.Join(restaurant.PaymentMethods, ", ")),
+			},
 			{
 				Type: "mrkdwn",
 				Text: fmt.Sprintf("*Donate URL:*\n%s", restaurant.DonateURL),
@@ -229,22 +616,57 @@ func (e RestaurantEntity) CreateRestaurantMsg(restaurant models.Restaurant, rest
 	}
 	msg.Blocks = append(msg.Blocks, detailsSection)
 
+	// the reject menu carries the listing id in each option's value
+	rejectOptions := []models.SlackMsgOption{}
+	for _, reason := range models.RejectionReasons {
+		rejectOptions = append(rejectOptions, models.SlackMsgOption{
+			Text: models.SlackMsgText{
+				Type: "plain_text",
+				Text: models.RejectionLabel(reason),
+			},
+			Value: restID + ":" + reason,
+		})
+	}
+
 	actionsSection := models.SlackMsgBlock{
 		Type: "actions",
 		Elements: &[]models.SlackMsgAction{
 			{
-				Type:  "button",
-				Style: "primary",
-				Value: restID,
-				Text: models.SlackMsgText{
+				ActionID: models.SlackActionApproveRestaurant,
+				Type:     "button",
+				Style:    "primary",
+				Value:    restID,
+				Text: &models.SlackMsgText{
 					Type: "plain_text",
 					Text: "Approve",
 				},
 			},
+			{
+				ActionID: models.SlackActionEditRestaurant,
+				Type:     "button",
+				Value:    restID,
+				Text: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Edit",
+				},
+			},
+			{
+				ActionID: models.SlackActionRejectRestaurant,
+				Type:     "static_select",
+				Placeholder: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Reject…",
+				},
+				Options: rejectOptions,
+			},
 		},
 	}
 	msg.Blocks = append(msg.Blocks, actionsSection)
 
+	postSlackMsg(msg)
+}
+
+func postSlackMsg(msg models.SlackMsg) {
 	payload, _ := json.Marshal(msg)
 
 	webhookURL := os.Getenv("SLACK_WEBHOOK_URL")
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+func TestListingConditionsOpenNow(t *testing.T) {
+	assert := assert.New(t)
+
+	closure := "NOT EXISTS (SELECT 1 FROM status_updates WHERE business_id = businesses.id AND type = '" + models.StatusTemporarilyClosed + "' AND " + activeStatusCondition + ")"
+
+	conditions, _ := listingConditions(0, 0, models.RestaurantFilters{})
+	assert.NotContains(strings.Join(conditions, " AND "), "status_updates")
+
+	// open_now drops listings with an active temporary closure
+	conditions, args := listingConditions(0, 0, models.RestaurantFilters{OpenNow: true})
+	assert.Contains(conditions, closure)
+	assert.Empty(args)
+
+	// public searches never include hidden, rejected or pending listings
+	for _, condition := range []string{"deleted_at IS null", "rejected_at IS null", "is_active IS TRUE", "is_hidden IS FALSE"} {
+		assert.Contains(conditions, condition)
+	}
+}
+
+func insertListing(t *testing.T, e RestaurantEntity, name, phone string, lat, lng float64) uint {
+	t.Helper()
+
+	var id uint
+	err := e.DB.Get(&id, `INSERT INTO businesses (name, type, email, phone, phone_e164, location, is_active, created_at, updated_at)
+		VALUES ($1, 'restaurant', '', $2, $2, ST_POINT($3, $4)::geography, TRUE, now(), now())
+		RETURNING id`, name, phone, lng, lat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func TestFindDuplicates(t *testing.T) {
+	assert := assert.New(t)
+
+	e := RestaurantEntity{DB: testDB(t)}
+
+	burgers := insertListing(t, e, "Bob's Burgers", "+16055550134", 43.5446, -96.7311)
+	insertListing(t, e, "Jimmy Pesto's Pizzeria", "+16055550199", 43.5447, -96.7311)
+
+	// a shared phone number matches wherever the listing is
+	duplicates, err := e.FindDuplicates(models.Restaurant{
+		Name:      "Completely Different",
+		PhoneE164: "+16055550134",
+		LatLng:    models.GeoPoint{Lat: 40.7128, Lng: -74.0060},
+	})
+	assert.NoError(err)
+	if assert.Len(duplicates, 1) {
+		assert.Equal(burgers, duplicates[0].ID)
+		assert.True(duplicates[0].PhoneMatch)
+	}
+
+	// a similar name about 50 m away matches
+	duplicates, err = e.FindDuplicates(models.Restaurant{
+		Name:   "Bobs Burgers",
+		LatLng: models.GeoPoint{Lat: 43.5450, Lng: -96.7311},
+	})
+	assert.NoError(err)
+	if assert.Len(duplicates, 1) {
+		assert.Equal(burgers, duplicates[0].ID)
+		assert.False(duplicates[0].PhoneMatch)
+		assert.Greater(duplicates[0].Similarity, duplicateNameThreshold)
+		if assert.NotNil(duplicates[0].DistanceMeters) {
+			assert.Less(*duplicates[0].DistanceMeters, 100.0)
+		}
+	}
+
+	// the same name across town doesn't
+	duplicates, err = e.FindDuplicates(models.Restaurant{
+		Name:   "Bob's Burgers",
+		LatLng: models.GeoPoint{Lat: 43.5900, Lng: -96.7311},
+	})
+	assert.NoError(err)
+	assert.Empty(duplicates)
+
+	// and neither does an unrelated name next door
+	duplicates, err = e.FindDuplicates(models.Restaurant{
+		Name:   "Fishoeder's",
+		LatLng: models.GeoPoint{Lat: 43.5446, Lng: -96.7312},
+	})
+	assert.NoError(err)
+	assert.Empty(duplicates)
+}
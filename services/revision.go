@@ -0,0 +1,33 @@
+package services
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+func recordRevision(tx *sqlx.Tx, revision models.Revision) error {
+	insert := `INSERT INTO business_revisions (
+		business_id,
+		original_business_id,
+		source,
+		actor,
+		summary,
+		changes,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := tx.Exec(
+		insert,
+		revision.BusinessID,
+		revision.BusinessID,
+		revision.Source,
+		revision.Actor,
+		revision.Summary,
+		revision.Changes,
+		time.Now(),
+	)
+
+	return err
+}
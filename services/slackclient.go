@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+const DefaultSlackAPIURL = "https://slack.com/api"
+
+// SlackAPIClient calls the Slack Web API with a bot token.
+type SlackAPIClient struct {
+	Token   string
+	BaseURL string
+	Client  *http.Client
+}
+
+// OpenView opens a modal in response to an interaction's trigger.
+func (c SlackAPIClient) OpenView(triggerID string, view models.SlackView) error {
+	return c.call("views.open", map[string]interface{}{
+		"trigger_id": triggerID,
+		"view":       view,
+	})
+}
+
+func (c SlackAPIClient) call(method string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultSlackAPIURL
+	}
+
+	req, err := http.NewRequest("POST", baseURL+"/"+method, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Slack %s: %v", method, err)
+	}
+	defer resp.Body.Close()
+
+	// Slack reports failures in the body, usually with a 200
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("Slack %s: %v", method, err)
+	}
+
+	if !result.OK {
+		return fmt.Errorf("Slack %s: %s", method, result.Error)
+	}
+
+	return nil
+}
+
+// MemorySlackClient records the views it is asked to open so tests can
+// inspect them.
+type MemorySlackClient struct {
+	mu    sync.Mutex
+	views []models.SlackView
+}
+
+func (c *MemorySlackClient) OpenView(triggerID string, view models.SlackView) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.views = append(c.views, view)
+	return nil
+}
+
+// Views returns a copy of every view opened so far.
+func (c *MemorySlackClient) Views() []models.SlackView {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]models.SlackView{}, c.views...)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+func TestSlackAPIClientOpenView(t *testing.T) {
+	assert := assert.New(t)
+
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/views.open", r.URL.Path)
+		assert.Equal("Bearer xoxb-test", r.Header.Get("Authorization"))
+		json.NewDecoder(r.Body).Decode(&received)
+
+		if received["trigger_id"] == "expired" {
+			w.Write([]byte(`{"ok": false, "error": "expired_trigger_id"}`))
+			return
+		}
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	client := SlackAPIClient{Token: "xoxb-test", BaseURL: server.URL}
+	view := models.SlackView{Type: "modal", CallbackID: "edit_restaurant"}
+
+	assert.NoError(client.OpenView("123.456", view))
+	assert.Equal("123.456", received["trigger_id"])
+	assert.Equal("edit_restaurant", received["view"].(map[string]interface{})["callback_id"])
+
+	err := client.OpenView("expired", view)
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "expired_trigger_id")
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+// activeStatusCondition matches approved status updates that have started and
+// not yet expired.
+const activeStatusCondition = `is_approved IS TRUE AND deleted_at IS null AND starts_at <= NOW() AND ends_at > NOW()`
+
+type StatusUpdateEntity struct {
+	DB *sqlx.DB
+}
+
+func (e StatusUpdateEntity) CreateStatusUpdate(newStatus models.StatusUpdate, restaurant models.Restaurant) (*uint, error) {
+	now := time.Now()
+
+	insert := `INSERT INTO status_updates (
+		business_id,
+		type,
+		message,
+		starts_at,
+		ends_at,
+		is_approved,
+		created_at,
+		updated_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var newID uint
+
+	err = tx.QueryRow(
+		insert,
+		newStatus.BusinessID,
+		newStatus.Type,
+		newStatus.Message,
+		newStatus.StartsAt,
+		newStatus.EndsAt,
+		newStatus.IsApproved,
+		now,
+		now,
+	).Scan(&newID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setTranslations(tx, models.TranslationStatusUpdate, newID, newStatus.Translations); err != nil {
+		return nil, err
+	}
+
+	// owners post pre-approved updates, which don't need a moderator and
+	// count towards the listing's freshness
+	if newStatus.IsApproved {
+		update := `UPDATE businesses SET owner_updated_at = $1 WHERE id = $2`
+		if _, err := tx.Exec(update, now, newStatus.BusinessID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if !newStatus.IsApproved {
+		e.CreateStatusUpdateMsg(newStatus, restaurant, strconv.Itoa(int(newID)))
+	}
+
+	return &newID, nil
+}
+
+// ApproveStatusUpdate publishes a pending status update. It reports whether
+// this call approved it, so a second click leaves the first reviewer on
+// record.
+func (e StatusUpdateEntity) ApproveStatusUpdate(statusID uint, reviewer string) (*models.StatusUpdate, bool, error) {
+	now := time.Now()
+
+	update := `UPDATE status_updates SET is_approved = TRUE, approved_at = $1, approved_by = $2, updated_at = $1 WHERE id = $3 AND is_approved IS FALSE`
+	result, err := e.DB.Exec(update, now, reviewer, statusID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	approved, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	query := `SELECT * FROM status_updates WHERE id = $1`
+	var status models.StatusUpdate
+	if err := e.DB.QueryRowx(query, statusID).StructScan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return &status, approved > 0, nil
+}
+
+// getActiveStatuses returns the current status of each restaurant that has
+// one, preferring closures over other updates and the most recent otherwise.
+func (e StatusUpdateEntity) getActiveStatuses(restaurantIDs []uint) (map[uint]models.StatusUpdate, error) {
+	statuses := map[uint]models.StatusUpdate{}
+	if len(restaurantIDs) == 0 {
+		return statuses, nil
+	}
+
+	ids := make([]int64, len(restaurantIDs))
+	for i, id := range restaurantIDs {
+		ids[i] = int64(id)
+	}
+
+	query := fmt.Sprintf(`SELECT * FROM status_updates WHERE business_id = ANY($1) AND %s`, activeStatusCondition)
+
+	rows, err := e.DB.Queryx(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := map[uint][]models.StatusUpdate{}
+	for rows.Next() {
+		var status models.StatusUpdate
+		if err := rows.StructScan(&status); err != nil {
+			return nil, err
+		}
+		candidates[status.BusinessID] = append(candidates[status.BusinessID], status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for restaurantID, updates := range candidates {
+		if status := models.ActiveStatus(updates, now); status != nil {
+			statuses[restaurantID] = *status
+		}
+	}
+
+	return statuses, nil
+}
+
+func (e StatusUpdateEntity) CreateStatusUpdateMsg(status models.StatusUpdate, restaurant models.Restaurant, statusID string) {
+	msg := models.SlackMsg{}
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "section",
+		Text: &models.SlackMsgText{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf(
+				"A status update has been submitted for *%s* (#%d):\n*%s* from %s until %s\n\n%s",
+				restaurant.Name,
+				restaurant.ID,
+				status.Type,
+				status.StartsAt.Format("Jan 2 3:04 PM"),
+				status.EndsAt.Format("Jan 2 3:04 PM"),
+				status.Message,
+			),
+		},
+	})
+
+	msg.Blocks = append(msg.Blocks, models.SlackMsgBlock{
+		Type: "actions",
+		Elements: &[]models.SlackMsgAction{
+			{
+				ActionID: models.SlackActionApproveStatus,
+				Type:     "button",
+				Style:    "primary",
+				Value:    statusID,
+				Text: &models.SlackMsgText{
+					Type: "plain_text",
+					Text: "Approve Status",
+				},
+			},
+		},
+	})
+
+	postSlackMsg(msg)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Blob is a stored object opened for reading.
+type Blob interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+type LocalStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func (s LocalStorage) Put(key string, data io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("LocalStorage: %v", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("LocalStorage: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, data); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("LocalStorage: %v", err)
+	}
+
+	return nil
+}
+
+func (s LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("LocalStorage: %v", err)
+	}
+
+	return nil
+}
+
+// Open returns a stored file. Directories are reported as missing so they can
+// never be listed.
+func (s LocalStorage) Open(key string) (Blob, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, os.ErrNotExist
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.IsDir() {
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+
+	return file, nil
+}
+
+func (s LocalStorage) URL(key string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimRight(s.BaseURL, "/"), key)
+}
+
+func (s LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" {
+		return "", fmt.Errorf("LocalStorage: invalid key %q", key)
+	}
+
+	return filepath.Join(s.Dir, cleaned), nil
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+var ErrTagExists = errors.New("a tag with that slug already exists")
+
+// dedupeTags removes repeated tags from a businesses.tags expression while
+// keeping the order they were first listed in.
+const dedupeTags = `ARRAY(SELECT t FROM unnest(%s) WITH ORDINALITY AS u(t, n) GROUP BY t ORDER BY MIN(n))`
+
+type TagEntity struct {
+	DB *sqlx.DB
+}
+
+func (e TagEntity) GetTags() (*[]models.Tag, error) {
+	tags := []models.Tag{}
+
+	query := `SELECT * FROM tags ORDER BY slug ASC`
+	if err := e.DB.Select(&tags, query); err != nil {
+		return nil, err
+	}
+
+	return &tags, nil
+}
+
+func (e TagEntity) GetTag(slug string) (*models.Tag, error) {
+	query := `SELECT * FROM tags WHERE slug = $1`
+	var tag models.Tag
+	if err := e.DB.QueryRowx(query, slug).StructScan(&tag); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+func (e TagEntity) CreateTag(newTag models.Tag) (*models.Tag, error) {
+	now := time.Now()
+
+	insert := `INSERT INTO tags (slug, display_name, synonyms, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT (slug) DO NOTHING RETURNING id`
+
+	var newID uint
+	err := e.DB.QueryRow(insert, newTag.Slug, newTag.DisplayName, pq.Array(slugifyTags(newTag.Synonyms)), now, now).Scan(&newID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTagExists
+		}
+		return nil, err
+	}
+
+	return e.GetTag(newTag.Slug)
+}
+
+// RenameTag changes a tag's slug and display name. The old slug becomes a
+// synonym so later submissions still resolve, and every listing using it is
+// updated.
+func (e TagEntity) RenameTag(slug string, renamed models.Tag) (*models.Tag, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if renamed.Slug != slug {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM tags WHERE slug = $1)`, renamed.Slug).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrTagExists
+		}
+	}
+
+	update := `UPDATE tags SET
+		slug = $1,
+		display_name = $2,
+		synonyms = array_remove(ARRAY(SELECT DISTINCT unnest(synonyms || $3::text[] || ARRAY[$4::text])), $1),
+		updated_at = $5
+	WHERE slug = $4`
+
+	result, err := tx.Exec(update, renamed.Slug, renamed.DisplayName, pq.Array(slugifyTags(renamed.Synonyms)), slug, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, nil
+	}
+
+	if err := replaceBusinessTag(tx, slug, renamed.Slug); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return e.GetTag(renamed.Slug)
+}
+
+// MergeTags folds the source tag into the target. The source slug and its
+// synonyms are kept as synonyms of the target, and listings are retagged.
+func (e TagEntity) MergeTags(sourceSlug string, targetSlug string) (*models.Tag, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var sourceSynonyms pq.StringArray
+	err = tx.QueryRow(`DELETE FROM tags WHERE slug = $1 RETURNING synonyms`, sourceSlug).Scan(&sourceSynonyms)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	update := `UPDATE tags SET
+		synonyms = ARRAY(SELECT DISTINCT unnest(synonyms || $1::text[] || ARRAY[$2::text])),
+		updated_at = $3
+	WHERE slug = $4`
+
+	result, err := tx.Exec(update, sourceSynonyms, sourceSlug, time.Now(), targetSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, nil
+	}
+
+	if err := replaceBusinessTag(tx, sourceSlug, targetSlug); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return e.GetTag(targetSlug)
+}
+
+// NormalizeTags slugifies submitted tags and maps known synonyms onto their
+// canonical slug. Tags that are not in the taxonomy are kept as slugs.
+func (e TagEntity) NormalizeTags(tags []string) ([]string, error) {
+	slugs := slugifyTags(tags)
+	if len(slugs) == 0 {
+		return slugs, nil
+	}
+
+	query := `SELECT slug, synonyms FROM tags WHERE slug = ANY($1) OR synonyms && $1`
+	rows, err := e.DB.Query(query, pq.Array(slugs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	canonical := map[string]string{}
+	for rows.Next() {
+		var slug string
+		var synonyms pq.StringArray
+		if err := rows.Scan(&slug, &synonyms); err != nil {
+			return nil, err
+		}
+
+		canonical[slug] = slug
+		for _, synonym := range synonyms {
+			if _, ok := canonical[synonym]; !ok {
+				canonical[synonym] = slug
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, slug := range slugs {
+		if c, ok := canonical[slug]; ok {
+			slug = c
+		}
+
+		if !seen[slug] {
+			seen[slug] = true
+			normalized = append(normalized, slug)
+		}
+	}
+
+	return normalized, nil
+}
+
+func replaceBusinessTag(tx *sqlx.Tx, from string, to string) error {
+	update := fmt.Sprintf(`UPDATE businesses SET tags = %s WHERE $1 = ANY(tags)`, fmt.Sprintf(dedupeTags, "array_replace(tags, $1, $2)"))
+	_, err := tx.Exec(update, from, to)
+	return err
+}
+
+func slugifyTags(tags []string) []string {
+	slugs := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		slug := models.SlugifyTag(tag)
+		if slug != "" && !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+
+	return slugs
+}
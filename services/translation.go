@@ -0,0 +1,112 @@
+package services
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+type TranslationEntity struct {
+	DB *sqlx.DB
+}
+
+// LocalizeRestaurants swaps in the locale's translations of each listing's
+// text and current status message, keeping the default text where there is
+// no translation.
+func (e TranslationEntity) LocalizeRestaurants(restaurants []models.Restaurant, locale string) error {
+	if locale == models.DefaultLocale || len(restaurants) == 0 {
+		return nil
+	}
+
+	businessIDs := []uint{}
+	statusIDs := []uint{}
+	for _, r := range restaurants {
+		businessIDs = append(businessIDs, r.ID)
+		if r.Status != nil {
+			statusIDs = append(statusIDs, r.Status.ID)
+		}
+	}
+
+	businessTexts, err := e.getTranslations(models.TranslationBusiness, businessIDs, locale)
+	if err != nil {
+		return err
+	}
+
+	statusTexts, err := e.getTranslations(models.TranslationStatusUpdate, statusIDs, locale)
+	if err != nil {
+		return err
+	}
+
+	for i := range restaurants {
+		restaurants[i].Localize(businessTexts[restaurants[i].ID])
+		if restaurants[i].Status != nil {
+			restaurants[i].Status.Localize(statusTexts[restaurants[i].Status.ID])
+		}
+	}
+
+	return nil
+}
+
+// getTranslations returns translated text by entity id and then by field.
+func (e TranslationEntity) getTranslations(entityType string, entityIDs []uint, locale string) (map[uint]map[string]string, error) {
+	texts := map[uint]map[string]string{}
+	if len(entityIDs) == 0 {
+		return texts, nil
+	}
+
+	ids := make([]int64, len(entityIDs))
+	for i, id := range entityIDs {
+		ids[i] = int64(id)
+	}
+
+	query := `SELECT entity_id, field, text FROM translations WHERE entity_type = $1 AND entity_id = ANY($2) AND locale = $3`
+	rows, err := e.DB.Query(query, entityType, pq.Array(ids), locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entityID uint
+		var field, text string
+		if err := rows.Scan(&entityID, &field, &text); err != nil {
+			return nil, err
+		}
+
+		if texts[entityID] == nil {
+			texts[entityID] = map[string]string{}
+		}
+		texts[entityID][field] = text
+	}
+
+	return texts, rows.Err()
+}
+
+// setTranslations saves translations for an entity. Fields that aren't
+// mentioned are left alone and empty text removes a translation.
+func setTranslations(db sqlx.Execer, entityType string, entityID uint, translations models.Translations) error {
+	now := time.Now()
+
+	upsert := `INSERT INTO translations (entity_type, entity_id, field, locale, text, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (entity_type, entity_id, field, locale) DO UPDATE SET text = EXCLUDED.text, updated_at = EXCLUDED.updated_at`
+	remove := `DELETE FROM translations WHERE entity_type = $1 AND entity_id = $2 AND field = $3 AND locale = $4`
+
+	for locale, texts := range translations {
+		for field, text := range texts {
+			var err error
+			if text == "" {
+				_, err = db.Exec(remove, entityType, entityID, field, locale)
+			} else {
+				_, err = db.Exec(upsert, entityType, entityID, field, locale, text, now)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
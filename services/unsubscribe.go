@@ -0,0 +1,27 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type UnsubscribeEntity struct {
+	DB *sqlx.DB
+}
+
+// Unsubscribe stops notification emails to an address. Unsubscribing twice is
+// a no-op.
+func (e UnsubscribeEntity) Unsubscribe(email string) error {
+	insert := `INSERT INTO email_unsubscribes (email, created_at) VALUES ($1, $2) ON CONFLICT (email) DO NOTHING`
+	_, err := e.DB.Exec(insert, strings.ToLower(strings.TrimSpace(email)), time.Now())
+	return err
+}
+
+func (e UnsubscribeEntity) IsUnsubscribed(email string) (bool, error) {
+	var unsubscribed bool
+	query := `SELECT EXISTS (SELECT 1 FROM email_unsubscribes WHERE email = $1)`
+	err := e.DB.Get(&unsubscribed, query, strings.ToLower(strings.TrimSpace(email)))
+	return unsubscribed, err
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+// UpdateRestaurant applies a partial change to a listing and records it as a
+// revision made by the actor. It returns nil if the listing does not exist.
+func (e RestaurantEntity) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	tx, err := e.DB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updated, err := e.updateRestaurant(tx, id, update, models.Revision{
+		Source:  source,
+		Actor:   actor,
+		Summary: "Updated by " + actor,
+	})
+	if err != nil || updated == nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return e.GetRestaurant(id)
+}
+
+// updateRestaurant is the shared update path. Phone numbers and tags are
+// normalized the same way as on create, and the revision is only recorded if
+// something actually changed.
+func (e RestaurantEntity) updateRestaurant(tx *sqlx.Tx, id uint, update models.RestaurantUpdate, revision models.Revision) (*models.Restaurant, error) {
+	before, err := lockRestaurant(tx, id)
+	if err != nil || before == nil {
+		return nil, err
+	}
+
+	after := *before
+	update.Apply(&after)
+
+	if update.ChangesAddress() {
+		after.NormalizeAddress()
+	}
+
+	if update.Phone != nil || update.Country != nil {
+		if err := after.NormalizePhone(); err != nil {
+			return nil, err
+		}
+	}
+
+	if update.Tags != nil {
+		tags, err := TagEntity{DB: e.DB}.NormalizeTags(after.Tags)
+		if err != nil {
+			return nil, err
+		}
+		after.Tags = tags
+	}
+
+	after.OrderingChannels.Sort()
+
+	statement := `UPDATE businesses SET
+		name = $1,
+		type = $2,
+		email = $3,
+		phone = $4,
+		phone_e164 = $5,
+		phone_extension = $6,
+		details = $7,
+		hours = $8,
+		url = $9,
+		address = $10,
+		address2 = $11,
+		city = $12,
+		state = $13,
+		zipcode = $14,
+		country = $15,
+		donate_url = $16,
+		giftcard = $17,
+		tags = $18,
+		ordering_channels = $19,
+		takeout = $20,
+		curbside = $21,
+		delivery = $22,
+		drive_through = $23,
+		payment_methods = $24,
+		location = CASE WHEN $25 THEN ST_POINT($26, $27)::geography ELSE location END,
+		updated_at = $28,
+		owner_updated_at = CASE WHEN $30 THEN $28 ELSE owner_updated_at END
+	WHERE id = $29`
+
+	_, err = tx.Exec(
+		statement,
+		after.Name,
+		after.Type,
+		after.Email,
+		after.Phone,
+		after.PhoneE164,
+		after.PhoneExtension,
+		after.Details,
+		after.Hours,
+		after.URL,
+		after.Address,
+		after.Address2,
+		after.City,
+		after.State,
+		after.Zipcode,
+		after.Country,
+		after.DonateURL,
+		after.HasGiftCard,
+		after.Tags,
+		after.OrderingChannels,
+		after.Takeout,
+		after.Curbside,
+		after.Delivery,
+		after.DriveThrough,
+		after.PaymentMethods,
+		update.LatLng != nil,
+		after.LatLng.Lng,
+		after.LatLng.Lat,
+		time.Now(),
+		id,
+		revision.Source == models.RevisionSourceOwner,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Translations != nil {
+		if err := setTranslations(tx, models.TranslationBusiness, id, *update.Translations); err != nil {
+			return nil, err
+		}
+	}
+
+	changes := models.DiffRestaurants(*before, after)
+	if len(changes) > 0 {
+		revision.BusinessID = id
+		revision.Changes = changes
+		if err := recordRevision(tx, revision); err != nil {
+			return nil, err
+		}
+	}
+
+	return &after, nil
+}
@@ -0,0 +1,244 @@
+package slackadmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+// maxCommandResults keeps command responses well under Slack's block limit.
+const maxCommandResults = 20
+
+const commandHelp = "Usage:\n" +
+	"`/takeout pending` lists submissions waiting for approval\n" +
+	"`/takeout find <name|zip>` searches listings\n" +
+	"`/takeout hide <id>` takes a listing off the site\n" +
+	"`/takeout approve <id>` approves a submission\n" +
+	"`/takeout stats` shows moderation counts"
+
+// command handles the /takeout slash command. Every response is ephemeral.
+func (c *Controller) command(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.PostFormValue("team_id") != os.Getenv("SLACK_TEAM_ID") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// like the buttons, commands only work in the moderation channel
+	if r.PostFormValue("channel_id") != os.Getenv("SLACK_CHANNEL_ID") {
+		c.reply(w, "`/takeout` only works in the moderation channel")
+		return
+	}
+
+	fields := strings.Fields(r.PostFormValue("text"))
+	if len(fields) == 0 {
+		c.reply(w, commandHelp)
+		return
+	}
+
+	moderator := r.PostFormValue("user_name")
+	argument := strings.Join(fields[1:], " ")
+
+	switch strings.ToLower(fields[0]) {
+	case "pending":
+		c.pendingCommand(w)
+	case "find":
+		c.findCommand(w, argument)
+	case "hide":
+		c.hideCommand(w, argument, moderator)
+	case "approve":
+		c.approveCommand(w, argument, moderator)
+	case "stats":
+		c.statsCommand(w)
+	default:
+		c.reply(w, commandHelp)
+	}
+}
+
+func (c *Controller) pendingCommand(w http.ResponseWriter) {
+	restaurants, err := c.e.GetSubmissions(models.SubmissionPending)
+	if err != nil {
+		c.commandError(w, err)
+		return
+	}
+
+	if len(*restaurants) == 0 {
+		c.reply(w, "Nothing is waiting for approval :tada:")
+		return
+	}
+
+	c.replyWithListings(w, fmt.Sprintf("*%d* submissions waiting for approval, oldest first:", len(*restaurants)), *restaurants)
+}
+
+func (c *Controller) findCommand(w http.ResponseWriter, term string) {
+	if term == "" {
+		c.reply(w, "Usage: `/takeout find <name|zip>`")
+		return
+	}
+
+	restaurants, err := c.e.SearchRestaurants(term, maxCommandResults)
+	if err != nil {
+		c.commandError(w, err)
+		return
+	}
+
+	if len(*restaurants) == 0 {
+		c.reply(w, fmt.Sprintf("No listings match _%s_", term))
+		return
+	}
+
+	c.replyWithListings(w, fmt.Sprintf("Listings matching _%s_:", term), *restaurants)
+}
+
+func (c *Controller) hideCommand(w http.ResponseWriter, argument string, moderator string) {
+	restID, err := strconv.ParseUint(argument, 10, 64)
+	if err != nil {
+		c.reply(w, "Usage: `/takeout hide <id>`")
+		return
+	}
+
+	restaurant, hidden, err := c.e.HideRestaurant(uint(restID), moderator)
+	if err != nil {
+		c.commandError(w, err)
+		return
+	}
+
+	if restaurant == nil {
+		c.reply(w, fmt.Sprintf("Listing #%d was not found", restID))
+		return
+	}
+
+	if !hidden {
+		c.reply(w, fmt.Sprintf("*%s* (#%d) is already hidden", restaurant.Name, restaurant.ID))
+		return
+	}
+
+	c.reply(w, fmt.Sprintf("*%s* (#%d) has been hidden by %s", restaurant.Name, restaurant.ID, moderator))
+}
+
+func (c *Controller) approveCommand(w http.ResponseWriter, argument string, moderator string) {
+	restID, err := strconv.ParseUint(argument, 10, 64)
+	if err != nil {
+		c.reply(w, "Usage: `/takeout approve <id>`")
+		return
+	}
+
+	restaurant, approved, err := c.e.ApproveRestaurant(uint(restID), moderator, false)
+	if err != nil {
+		c.commandError(w, err)
+		return
+	}
+
+	if restaurant == nil {
+		c.reply(w, fmt.Sprintf("Listing #%d was not found", restID))
+		return
+	}
+
+	if !approved && restaurant.RejectedAt != nil {
+		c.reply(w, fmt.Sprintf("*%s* (#%d) was already rejected, use the admin API to overrule it", restaurant.Name, restaurant.ID))
+		return
+	}
+
+	if !approved {
+		c.reply(w, fmt.Sprintf("*%s* (#%d) is already approved", restaurant.Name, restaurant.ID))
+		return
+	}
+
+	if err := c.n.SubmissionApproved(*restaurant); err != nil {
+		sentry.CaptureException(err)
+	}
+
+	c.reply(w, fmt.Sprintf("*%s* (#%d) has been approved by %s", restaurant.Name, restaurant.ID, moderator))
+}
+
+func (c *Controller) statsCommand(w http.ResponseWriter) {
+	stats, err := c.e.GetModerationStats()
+	if err != nil {
+		c.commandError(w, err)
+		return
+	}
+
+	c.respondToCommand(w, models.SlackCommandResponse{
+		ResponseType: "ephemeral",
+		Text:         "Moderation stats",
+		Blocks: []models.SlackMsgBlock{
+			{
+				Type: "section",
+				Fields: &[]models.SlackMsgText{
+					{Type: "mrkdwn", Text: fmt.Sprintf("*Pending:*\n%d", stats.Pending)},
+					{Type: "mrkdwn", Text: fmt.Sprintf("*Live:*\n%d", stats.Approved)},
+					{Type: "mrkdwn", Text: fmt.Sprintf("*Hidden:*\n%d", stats.Hidden)},
+					{Type: "mrkdwn", Text: fmt.Sprintf("*Rejected:*\n%d", stats.Rejected)},
+					{Type: "mrkdwn", Text: fmt.Sprintf("*Open reports:*\n%d", stats.OpenReports)},
+					{Type: "mrkdwn", Text: fmt.Sprintf("*Pending edits:*\n%d", stats.PendingEdits)},
+				},
+			},
+		},
+	})
+}
+
+func (c *Controller) replyWithListings(w http.ResponseWriter, heading string, restaurants []models.Restaurant) {
+	lines := []string{}
+	for i, restaurant := range restaurants {
+		if i == maxCommandResults {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(restaurants)-maxCommandResults))
+			break
+		}
+
+		lines = append(lines, fmt.Sprintf("#%d *%s*, %s %s (%s)", restaurant.ID, restaurant.Name, restaurant.City, restaurant.State, restaurant.SubmissionStatus()))
+	}
+
+	c.respondToCommand(w, models.SlackCommandResponse{
+		ResponseType: "ephemeral",
+		Text:         heading,
+		Blocks: []models.SlackMsgBlock{
+			{
+				Type: "section",
+				Text: &models.SlackMsgText{Type: "mrkdwn", Text: heading},
+			},
+			{
+				Type: "section",
+				Text: &models.SlackMsgText{Type: "mrkdwn", Text: strings.Join(lines, "\n")},
+			},
+		},
+	})
+}
+
+func (c *Controller) reply(w http.ResponseWriter, text string) {
+	c.respondToCommand(w, models.SlackCommandResponse{
+		ResponseType: "ephemeral",
+		Text:         text,
+		Blocks: []models.SlackMsgBlock{
+			{
+				Type: "section",
+				Text: &models.SlackMsgText{Type: "mrkdwn", Text: text},
+			},
+		},
+	})
+}
+
+// commandError reports a failure to the moderator. Slack only shows command
+// responses sent with a 200.
+func (c *Controller) commandError(w http.ResponseWriter, err error) {
+	eventID := sentry.CaptureException(err)
+	c.reply(w, fmt.Sprintf("There was a problem running that command: ID: %v", eventID))
+}
+
+func (c *Controller) respondToCommand(w http.ResponseWriter, response models.SlackCommandResponse) {
+	payload, _ := json.Marshal(response)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
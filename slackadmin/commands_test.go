@@ -0,0 +1,196 @@
+package slackadmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type mockCommandEntity struct {
+	mockEntityInterface
+	hidden []uint
+}
+
+func (e *mockCommandEntity) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	pending := *testRestaurant()
+	return &[]models.Restaurant{pending}, nil
+}
+
+func (e *mockCommandEntity) SearchRestaurants(term string, limit int) (*[]models.Restaurant, error) {
+	restaurants := []models.Restaurant{}
+	if strings.Contains("Bob's Burgers 57104", term) {
+		restaurant := *testRestaurant()
+		restaurant.IsActive = true
+		restaurants = append(restaurants, restaurant)
+	}
+
+	return &restaurants, nil
+}
+
+func (e *mockCommandEntity) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	if restaurantID != 4 {
+		return nil, false, nil
+	}
+
+	if len(e.hidden) > 0 {
+		return testRestaurant(), false, nil
+	}
+
+	e.hidden = append(e.hidden, restaurantID)
+	return testRestaurant(), true, nil
+}
+
+func (e *mockCommandEntity) GetModerationStats() (*models.ModerationStats, error) {
+	return &models.ModerationStats{Pending: 3, Approved: 120, Hidden: 2, Rejected: 7, OpenReports: 4, PendingEdits: 1}, nil
+}
+
+func runCommand(text string) *http.Request {
+	return runCommandIn("", text)
+}
+
+func runCommandIn(channelID string, text string) *http.Request {
+	body := url.Values{
+		"command":    {"/takeout"},
+		"text":       {text},
+		"team_id":    {""},
+		"channel_id": {channelID},
+		"user_name":  {"linda"},
+	}.Encode()
+
+	return signedRequestTo("/slackadmin/commands/", body, time.Now(), testVerifier.SigningSecret)
+}
+
+func commandResponse(t *testing.T, c Controller, text string) models.SlackCommandResponse {
+	rr := serve(c, runCommand(text))
+	assert.Equal(t, http.StatusOK, rr.Code, text)
+
+	var response models.SlackCommandResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response), text)
+	assert.Equal(t, "ephemeral", response.ResponseType, text)
+
+	return response
+}
+
+func blocksText(response models.SlackCommandResponse) string {
+	texts := []string{}
+	for _, block := range response.Blocks {
+		if block.Text != nil {
+			texts = append(texts, block.Text.Text)
+		}
+		if block.Fields != nil {
+			for _, field := range *block.Fields {
+				texts = append(texts, field.Text)
+			}
+		}
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+func buildCommandController(e *mockCommandEntity) Controller {
+	return NewController(mux.NewRouter(), e, nil, nil, nil, nil, mockGeocoder{}, &services.MemorySlackClient{}, services.SubmissionNotifier{}, testVerifier)
+}
+
+func TestCommands(t *testing.T) {
+	assert := assert.New(t)
+
+	e := &mockCommandEntity{mockEntityInterface: mockEntityInterface{testRest: testRestaurant()}}
+	c := buildCommandController(e)
+
+	response := commandResponse(t, c, "pending")
+	assert.Contains(blocksText(response), "#4 *Bob's Burgers*, Sioux Falls SD (pending)")
+
+	response = commandResponse(t, c, "find 57104")
+	assert.Contains(blocksText(response), "#4 *Bob's Burgers*, Sioux Falls SD (approved)")
+
+	response = commandResponse(t, c, "find Krusty Burger")
+	assert.Contains(blocksText(response), "No listings match _Krusty Burger_")
+
+	response = commandResponse(t, c, "stats")
+	assert.Contains(blocksText(response), "*Pending:*\n3")
+	assert.Contains(blocksText(response), "*Open reports:*\n4")
+
+	response = commandResponse(t, c, "hide 4")
+	assert.Contains(blocksText(response), "has been hidden by linda")
+	assert.Equal([]uint{4}, e.hidden)
+
+	response = commandResponse(t, c, "hide 4")
+	assert.Contains(blocksText(response), "is already hidden")
+	assert.Equal([]uint{4}, e.hidden)
+
+	response = commandResponse(t, c, "hide 9")
+	assert.Contains(blocksText(response), "#9 was not found")
+
+	response = commandResponse(t, c, "approve 4")
+	assert.Contains(blocksText(response), "has been approved by linda")
+	assert.Equal([]uint{4}, e.approved)
+
+	response = commandResponse(t, c, "dance")
+	assert.Contains(blocksText(response), "Usage:")
+
+	response = commandResponse(t, c, "hide")
+	assert.Contains(blocksText(response), "Usage: `/takeout hide <id>`")
+}
+
+func TestApproveCommandSkipsApprovedListings(t *testing.T) {
+	assert := assert.New(t)
+
+	approved := testRestaurant()
+	approved.IsActive = true
+
+	e := &mockCommandEntity{mockEntityInterface: mockEntityInterface{testRest: approved}}
+	c := buildCommandController(e)
+
+	response := commandResponse(t, c, "approve 4")
+	assert.Contains(blocksText(response), "is already approved")
+	assert.Empty(e.approved)
+}
+
+func TestApproveCommandSkipsRejectedListings(t *testing.T) {
+	assert := assert.New(t)
+
+	rejectedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+	rejected := testRestaurant()
+	rejected.RejectedAt = &rejectedAt
+
+	e := &mockCommandEntity{mockEntityInterface: mockEntityInterface{testRest: rejected}}
+	c := buildCommandController(e)
+
+	response := commandResponse(t, c, "approve 4")
+	assert.Contains(blocksText(response), "was already rejected")
+	assert.Empty(e.approved)
+}
+
+func TestCommandsOnlyRunInModerationChannel(t *testing.T) {
+	assert := assert.New(t)
+
+	e := &mockCommandEntity{mockEntityInterface: mockEntityInterface{testRest: testRestaurant()}}
+	c := buildCommandController(e)
+
+	for _, text := range []string{"hide 4", "approve 4", "pending"} {
+		rr := serve(c, runCommandIn("D0123456", text))
+		assert.Equal(http.StatusOK, rr.Code, text)
+		assert.Contains(rr.Body.String(), "only works in the moderation channel", text)
+	}
+
+	assert.Empty(e.hidden)
+	assert.Empty(e.approved)
+}
+
+func TestCommandsRequireSignature(t *testing.T) {
+	c := buildCommandController(&mockCommandEntity{})
+
+	body := url.Values{"command": {"/takeout"}, "text": {"stats"}, "team_id": {""}}.Encode()
+	req := signedRequestTo("/slackadmin/commands/", body, time.Now(), []byte("not-the-secret"))
+
+	rr := serve(c, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,351 @@
+package slackadmin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-playground/validator"
+	"github.com/theproducer/openfortakeout_api/models"
+)
+
+// editModalCallbackID identifies the edit modal's view_submission.
+const editModalCallbackID = "edit_restaurant"
+
+// modalValueAction is the action id of every input in the edit modal.
+const modalValueAction = "value"
+
+// modalField is a listing field shown in the edit modal. The block id matches
+// the field's JSON name and field is its name in validation errors.
+type modalField struct {
+	blockID   string
+	label     string
+	field     string
+	optional  bool
+	multiline bool
+	get       func(r models.Restaurant) string
+	set       func(u *models.RestaurantUpdate, value string)
+}
+
+var editModalFields = []modalField{
+	{
+		blockID: "name",
+		label:   "Name",
+		field:   "Name",
+		get:     func(r models.Restaurant) string { return r.Name },
+		set:     func(u *models.RestaurantUpdate, value string) { u.Name = &value },
+	},
+	{
+		blockID: "type",
+		label:   "Type",
+		field:   "Type",
+		get:     func(r models.Restaurant) string { return r.Type },
+		set:     func(u *models.RestaurantUpdate, value string) { u.Type = &value },
+	},
+	{
+		blockID:  "tags",
+		label:    "Tags (comma separated)",
+		field:    "Tags",
+		optional: true,
+		get:      func(r models.Restaurant) string { return strings.Join(r.Tags, ", ") },
+		set: func(u *models.RestaurantUpdate, value string) {
+			tags := models.RestaurantTags{}
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			u.Tags = &tags
+		},
+	},
+	{
+		blockID: "phone",
+		label:   "Phone",
+		field:   "Phone",
+		get:     func(r models.Restaurant) string { return r.Phone },
+		set:     func(u *models.RestaurantUpdate, value string) { u.Phone = &value },
+	},
+	{
+		blockID: "email",
+		label:   "Email",
+		field:   "Email",
+		get:     func(r models.Restaurant) string { return r.Email },
+		set:     func(u *models.RestaurantUpdate, value string) { u.Email = &value },
+	},
+	{
+		blockID:  "url",
+		label:    "URL",
+		field:    "URL",
+		optional: true,
+		get:      func(r models.Restaurant) string { return r.URL },
+		set:      func(u *models.RestaurantUpdate, value string) { u.URL = &value },
+	},
+	{
+		blockID: "address",
+		label:   "Address",
+		field:   "Address",
+		get:     func(r models.Restaurant) string { return r.Address },
+		set:     func(u *models.RestaurantUpdate, value string) { u.Address = &value },
+	},
+	{
+		blockID:  "address_2",
+		label:    "Address line 2",
+		field:    "Address2",
+		optional: true,
+		get:      func(r models.Restaurant) string { return r.Address2 },
+		set:      func(u *models.RestaurantUpdate, value string) { u.Address2 = &value },
+	},
+	{
+		blockID: "city",
+		label:   "City",
+		field:   "City",
+		get:     func(r models.Restaurant) string { return r.City },
+		set:     func(u *models.RestaurantUpdate, value string) { u.City = &value },
+	},
+	{
+		blockID:  "state",
+		label:    "State",
+		field:    "State",
+		optional: true,
+		get:      func(r models.Restaurant) string { return r.State },
+		set:      func(u *models.RestaurantUpdate, value string) { u.State = &value },
+	},
+	{
+		blockID:  "zipcode",
+		label:    "Zipcode",
+		field:    "Zipcode",
+		optional: true,
+		get:      func(r models.Restaurant) string { return r.Zipcode },
+		set:      func(u *models.RestaurantUpdate, value string) { u.Zipcode = &value },
+	},
+	{
+		blockID: "country",
+		label:   "Country",
+		field:   "Country",
+		get:     func(r models.Restaurant) string { return r.Country },
+		set:     func(u *models.RestaurantUpdate, value string) { u.Country = &value },
+	},
+	{
+		blockID:  "donate_url",
+		label:    "Donate URL",
+		field:    "DonateURL",
+		optional: true,
+		get:      func(r models.Restaurant) string { return r.DonateURL },
+		set:      func(u *models.RestaurantUpdate, value string) { u.DonateURL = &value },
+	},
+	{
+		blockID:   "details",
+		label:     "Details",
+		field:     "Details",
+		optional:  true,
+		multiline: true,
+		get:       func(r models.Restaurant) string { return r.Details },
+		set:       func(u *models.RestaurantUpdate, value string) { u.Details = &value },
+	},
+	{
+		blockID:   "hours",
+		label:     "Hours",
+		field:     "Hours",
+		optional:  true,
+		multiline: true,
+		get:       func(r models.Restaurant) string { return r.Hours },
+		set:       func(u *models.RestaurantUpdate, value string) { u.Hours = &value },
+	},
+}
+
+// buildEditModal pre-fills a modal with the listing's current fields.
+func buildEditModal(restaurant models.Restaurant) models.SlackView {
+	blocks := []models.SlackMsgBlock{}
+	for _, field := range editModalFields {
+		blocks = append(blocks, models.SlackMsgBlock{
+			Type:    "input",
+			BlockID: field.blockID,
+			Label: &models.SlackMsgText{
+				Type: "plain_text",
+				Text: field.label,
+			},
+			Optional: field.optional,
+			Element: &models.SlackMsgAction{
+				ActionID:     modalValueAction,
+				Type:         "plain_text_input",
+				InitialValue: field.get(restaurant),
+				Multiline:    field.multiline,
+			},
+		})
+	}
+
+	return models.SlackView{
+		Type:            "modal",
+		CallbackID:      editModalCallbackID,
+		PrivateMetadata: strconv.Itoa(int(restaurant.ID)),
+		Title:           &models.SlackMsgText{Type: "plain_text", Text: "Edit submission"},
+		Submit:          &models.SlackMsgText{Type: "plain_text", Text: "Save"},
+		Close:           &models.SlackMsgText{Type: "plain_text", Text: "Cancel"},
+		Blocks:          blocks,
+	}
+}
+
+// openEditModal answers the Edit button by opening the modal.
+func (c *Controller) openEditModal(w http.ResponseWriter, slackResponse *models.SlackWebhookPost, value string) {
+	restID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		sentry.CaptureException(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		sentry.CaptureException(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	if err := c.slack.OpenView(slackResponse.TriggerID, buildEditModal(*restaurant)); err != nil {
+		sentry.CaptureException(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return
+}
+
+// submitEdit validates the modal with the same rules as new submissions and
+// saves the changed fields. Invalid fields are shown in the modal.
+func (c *Controller) submitEdit(w http.ResponseWriter, slackResponse *models.SlackWebhookPost) {
+	view := slackResponse.View
+
+	restID, err := strconv.ParseUint(view.PrivateMetadata, 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusBadRequest)
+		return
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		sentry.CaptureException(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	// only fields that were changed go into the update
+	update := models.RestaurantUpdate{}
+	for _, field := range editModalFields {
+		value := ""
+		if view.State != nil {
+			value = strings.TrimSpace(view.State.Values[field.blockID][modalValueAction].Value)
+		}
+
+		if value != field.get(*restaurant) {
+			field.set(&update, value)
+		}
+	}
+
+	proposed := *restaurant
+	update.Apply(&proposed)
+	proposed.NormalizeAddress()
+
+	fieldErrors := map[string]string{}
+
+	validate := models.NewValidator()
+	if err := validate.Struct(proposed); err != nil {
+		validationErrors, ok := err.(validator.ValidationErrors)
+		if !ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// problems with fields the modal doesn't show, like ordering channels,
+		// can't be fixed here and would leave Slack with nowhere to show them
+		for _, err := range validationErrors {
+			if modalShows(err.Field()) {
+				fieldErrors[err.Field()] = fmt.Sprintf("%s is missing or invalid", err.Field())
+			}
+		}
+	}
+
+	if _, ok := fieldErrors["Phone"]; !ok {
+		if err := proposed.NormalizePhone(); err != nil {
+			fieldErrors["Phone"] = "Phone is missing or invalid"
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		response := models.SlackViewErrors{
+			ResponseAction: "errors",
+			Errors:         map[string]string{},
+		}
+
+		for _, field := range editModalFields {
+			if message, ok := fieldErrors[field.field]; ok {
+				response.Errors[field.blockID] = message
+			}
+		}
+
+		payload, _ := json.Marshal(response)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(payload)
+		return
+	}
+
+	if !update.IsEmpty() {
+		if update.ChangesAddress() {
+			point, err := c.geocoder.GeocodeAddress(
+				fmt.Sprintf("%s %s", proposed.Address, proposed.Address2),
+				proposed.City,
+				proposed.State,
+				proposed.Zipcode,
+				proposed.Country,
+			)
+			if err != nil {
+				sentry.CaptureException(err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if point == nil {
+				point = &models.GeoPoint{}
+			}
+
+			update.LatLng = point
+		}
+
+		if _, err := c.e.UpdateRestaurant(restaurant.ID, update, models.RevisionSourceModeration, slackResponse.User.Name); err != nil {
+			sentry.CaptureException(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// an empty response closes the modal
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return
+}
+
+func modalShows(field string) bool {
+	for _, modalField := range editModalFields {
+		if modalField.field == field {
+			return true
+		}
+	}
+
+	return false
+}
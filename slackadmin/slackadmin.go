@@ -8,6 +8,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/getsentry/sentry-go"
 	"github.com/gorilla/mux"
@@ -16,14 +17,30 @@ import (
 )
 
 type Controller struct {
-	r *mux.Router
-	e services.RestaurantEntityInterface
+	r        *mux.Router
+	e        services.RestaurantEntityInterface
+	pe       services.PhotoEntityInterface
+	se       services.StatusUpdateEntityInterface
+	ee       services.EditEntityInterface
+	rpe      services.ReportEntityInterface
+	geocoder services.GeocodioServiceInterface
+	slack    services.SlackClient
+	n        services.SubmissionNotifier
+	v        services.SlackVerifier
 }
 
-func NewController(router *mux.Router, ei services.RestaurantEntityInterface) Controller {
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, pei services.PhotoEntityInterface, sei services.StatusUpdateEntityInterface, eei services.EditEntityInterface, rpei services.ReportEntityInterface, geocoder services.GeocodioServiceInterface, slack services.SlackClient, notifier services.SubmissionNotifier, verifier services.SlackVerifier) Controller {
 	c := Controller{
-		r: router,
-		e: ei,
+		r:        router,
+		e:        ei,
+		pe:       pei,
+		se:       sei,
+		ee:       eei,
+		rpe:      rpei,
+		geocoder: geocoder,
+		slack:    slack,
+		n:        notifier,
+		v:        verifier,
 	}
 
 	c.routes()
@@ -33,7 +50,9 @@ func NewController(router *mux.Router, ei services.RestaurantEntityInterface) Co
 
 func (c *Controller) routes() {
 	s := c.r.PathPrefix("/slackadmin").Subrouter()
+	s.Use(c.v.Middleware)
 	s.HandleFunc("/webhook/", c.webhook).Methods("POST")
+	s.HandleFunc("/commands/", c.command).Methods("POST")
 }
 
 func (c *Controller) webhook(w http.ResponseWriter, r *http.Request) {
@@ -59,43 +78,219 @@ func (c *Controller) webhook(w http.ResponseWriter, r *http.Request) {
 	slackTeamID := os.Getenv("SLACK_TEAM_ID")
 	slackChannelID := os.Getenv("SLACK_CHANNEL_ID")
 
+	// modal submissions come from the team but aren't tied to a channel
+	if slackResponse.Type == "view_submission" && slackResponse.View != nil && slackResponse.View.CallbackID == editModalCallbackID && slackResponse.Team.ID == slackTeamID {
+		c.submitEdit(w, slackResponse)
+		return
+	}
+
 	if slackResponse.Team.ID == slackTeamID && slackResponse.Channel.ID == slackChannelID {
 		if len(slackResponse.Actions) > 0 {
 			action := slackResponse.Actions[0]
 
-			if action.Text.Text == "Approve" {
-				// Approve the related submission
-				restID, err := strconv.ParseUint(action.Value, 10, 64)
+			if action.ActionID == models.SlackActionApprovePhoto {
+				photoID, err := strconv.ParseUint(action.Value, 10, 64)
 				if err != nil {
 					sentry.CaptureException(err)
 					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 
-				restaurant, err := c.e.ApproveRestaurant(uint(restID))
+				photo, approved, err := c.pe.ApprovePhoto(uint(photoID), slackResponse.User.Name)
 				if err != nil {
 					sentry.CaptureException(err)
 					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 
-				responseMsg := models.SlackMsgText{
-					Text: fmt.Sprintf("*%s* has been approved by %s", restaurant.Name, slackResponse.User.Name),
-					Type: "mrkdwn",
+				if photo == nil {
+					http.Error(w, "photo not found", http.StatusNotFound)
+					return
 				}
 
-				payload, _ := json.Marshal(responseMsg)
+				subject := fmt.Sprintf("Photo #%d for restaurant #%d", photo.ID, photo.BusinessID)
+				if !approved {
+					c.respond(w, slackResponse.ResponseURL, alreadyApproved(subject, photo.ApprovedBy), photo.ApprovedAt)
+					return
+				}
 
-				_, err = http.Post(slackResponse.ResponseURL, "application/json", bytes.NewBuffer(payload))
+				c.respond(w, slackResponse.ResponseURL, fmt.Sprintf("%s has been approved by %s", subject, *photo.ApprovedBy), photo.ApprovedAt)
+				return
+			}
+
+			if action.ActionID == models.SlackActionApproveStatus {
+				statusID, err := strconv.ParseUint(action.Value, 10, 64)
 				if err != nil {
 					sentry.CaptureException(err)
 					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusOK)
-				w.Write(payload)
+				status, approved, err := c.se.ApproveStatusUpdate(uint(statusID), slackResponse.User.Name)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				if status == nil {
+					http.Error(w, "status update not found", http.StatusNotFound)
+					return
+				}
+
+				subject := fmt.Sprintf("Status update #%d for restaurant #%d", status.ID, status.BusinessID)
+				if !approved {
+					c.respond(w, slackResponse.ResponseURL, alreadyApproved(subject, status.ApprovedBy), status.ApprovedAt)
+					return
+				}
+
+				c.respond(w, slackResponse.ResponseURL, fmt.Sprintf("%s has been approved by %s", subject, *status.ApprovedBy), status.ApprovedAt)
+				return
+			}
+
+			if action.ActionID == models.SlackActionApproveEdit || action.ActionID == models.SlackActionRejectEdit {
+				editID, err := strconv.ParseUint(action.Value, 10, 64)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				review := c.ee.RejectEdit
+				if action.ActionID == models.SlackActionApproveEdit {
+					review = c.ee.ApproveEdit
+				}
+
+				edit, err := review(uint(editID), slackResponse.User.Name)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				if edit == nil {
+					http.Error(w, "edit not found", http.StatusNotFound)
+					return
+				}
+
+				c.respond(w, slackResponse.ResponseURL, fmt.Sprintf("Edit #%d for restaurant #%d has been %s by %s", edit.ID, edit.BusinessID, edit.Status, *edit.ReviewedBy), edit.ReviewedAt)
+				return
+			}
+
+			if action.ActionID == models.SlackActionHideReport || action.ActionID == models.SlackActionDismissReport {
+				reportID, err := strconv.ParseUint(action.Value, 10, 64)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				review := c.rpe.DismissReport
+				if action.ActionID == models.SlackActionHideReport {
+					review = c.rpe.HideReported
+				}
+
+				report, err := review(uint(reportID), slackResponse.User.Name)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				if report == nil {
+					http.Error(w, "report not found", http.StatusNotFound)
+					return
+				}
+
+				outcome := "dismissed"
+				if report.Status == models.ReportActioned {
+					outcome = "hidden"
+				}
+
+				c.respond(w, slackResponse.ResponseURL, fmt.Sprintf("Report #%d for restaurant #%d has been %s by %s", report.ID, report.BusinessID, outcome, *report.ReviewedBy), report.ReviewedAt)
+				return
+			}
+
+			if action.ActionID == models.SlackActionEditRestaurant {
+				c.openEditModal(w, slackResponse, action.Value)
+				return
+			}
+
+			if action.ActionID == models.SlackActionRejectRestaurant && action.SelectedOption != nil {
+				// options are valued "<restaurant id>:<reason>"
+				parts := strings.SplitN(action.SelectedOption.Value, ":", 2)
+				if len(parts) != 2 || !models.IsRejectionReason(parts[1]) {
+					http.Error(w, "unknown rejection reason", http.StatusBadRequest)
+					return
+				}
+
+				restID, err := strconv.ParseUint(parts[0], 10, 64)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				restaurant, rejected, err := c.e.RejectRestaurant(uint(restID), parts[1], slackResponse.User.Name, false)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				if restaurant == nil {
+					http.Error(w, "restaurant not found", http.StatusNotFound)
+					return
+				}
+
+				// card decisions are final, so a late click only gets told who was first
+				if !rejected {
+					text, decidedAt := alreadyDecided(*restaurant)
+					c.respond(w, slackResponse.ResponseURL, text, decidedAt)
+					return
+				}
+
+				if err := c.n.SubmissionRejected(*restaurant); err != nil {
+					sentry.CaptureException(err)
+				}
+
+				c.respond(w, slackResponse.ResponseURL, fmt.Sprintf("*%s* has been rejected by %s: %s", restaurant.Name, *restaurant.RejectedBy, models.RejectionLabel(*restaurant.RejectionReason)), restaurant.RejectedAt)
+				return
+			}
+
+			if action.ActionID == models.SlackActionApproveRestaurant || action.Text.Text == "Approve" {
+				// Approve the related submission
+				restID, err := strconv.ParseUint(action.Value, 10, 64)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				restaurant, approved, err := c.e.ApproveRestaurant(uint(restID), slackResponse.User.Name, false)
+				if err != nil {
+					sentry.CaptureException(err)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				if restaurant == nil {
+					http.Error(w, "restaurant not found", http.StatusNotFound)
+					return
+				}
+
+				// a second click, or another moderator, only gets told who was first
+				if !approved {
+					text, decidedAt := alreadyDecided(*restaurant)
+					c.respond(w, slackResponse.ResponseURL, text, decidedAt)
+					return
+				}
+
+				if err := c.n.SubmissionApproved(*restaurant); err != nil {
+					sentry.CaptureException(err)
+				}
+
+				c.respond(w, slackResponse.ResponseURL, fmt.Sprintf("*%s* has been approved by %s", restaurant.Name, *restaurant.ApprovedBy), restaurant.ApprovedAt)
 				return
 			}
 
@@ -111,5 +306,74 @@ func (c *Controller) webhook(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
 	return
+}
+
+// respond replaces the message that was acted on with the decision, which
+// removes its buttons, and echoes it as the webhook response.
+func (c *Controller) respond(w http.ResponseWriter, responseURL string, text string, decidedAt *time.Time) {
+	if decidedAt != nil {
+		text = fmt.Sprintf("%s on %s", text, slackDate(*decidedAt))
+	}
+
+	responseMsg := models.SlackResponseMsg{
+		ReplaceOriginal: true,
+		Text:            text,
+		Blocks: []models.SlackMsgBlock{
+			{
+				Type: "section",
+				Text: &models.SlackMsgText{
+					Type: "mrkdwn",
+					Text: text,
+				},
+			},
+		},
+	}
+
+	payload, _ := json.Marshal(responseMsg)
+
+	_, err := http.Post(responseURL, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		sentry.CaptureException(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(payload)
+}
+
+// slackDate shows a time in each reader's own timezone, falling back to UTC.
+func slackDate(t time.Time) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>", t.Unix(), t.UTC().Format("Jan 2, 2006 15:04 MST"))
+}
+
+// alreadyApproved names whoever got there first. Items approved before
+// reviewers were recorded have no name.
+// alreadyDecided tells a moderator how a submission was already decided, and
+// when.
+func alreadyDecided(restaurant models.Restaurant) (string, *time.Time) {
+	subject := fmt.Sprintf("*%s*", restaurant.Name)
+
+	if restaurant.RejectedAt == nil {
+		return alreadyApproved(subject, restaurant.ApprovedBy), restaurant.ApprovedAt
+	}
+
+	text := subject + " was already rejected"
+	if restaurant.RejectedBy != nil {
+		text += " by " + *restaurant.RejectedBy
+	}
+	if restaurant.RejectionReason != nil {
+		text += ": " + models.RejectionLabel(*restaurant.RejectionReason)
+	}
+
+	return text, restaurant.RejectedAt
+}
+
+func alreadyApproved(subject string, approvedBy *string) string {
+	if approvedBy == nil {
+		return subject + " was already approved"
+	}
 
+	return fmt.Sprintf("%s was already approved by %s", subject, *approvedBy)
 }
@@ -0,0 +1,623 @@
+package slackadmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+var testVerifier = services.SlackVerifier{
+	SigningSecret: []byte("8f742231b10e8888abcd99yyyzzz85a5"),
+	ReplayWindow:  services.DefaultSlackReplayWindow,
+}
+
+type mockEntityInterface struct {
+	services.RestaurantEntityInterface
+	testRest *models.Restaurant
+	updates  []models.RestaurantUpdate
+	actors   []string
+	approved []uint
+	rejected []string
+}
+
+func (e *mockEntityInterface) GetRestaurant(id uint) (*models.Restaurant, error) {
+	if e.testRest == nil || e.testRest.ID != id {
+		return nil, nil
+	}
+
+	return e.testRest, nil
+}
+
+func (e *mockEntityInterface) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	e.updates = append(e.updates, update)
+	e.actors = append(e.actors, actor)
+
+	updated := *e.testRest
+	update.Apply(&updated)
+	return &updated, nil
+}
+
+func (e *mockEntityInterface) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	if e.testRest.IsActive || (e.testRest.RejectedAt != nil && !overrule) {
+		return e.testRest, false, nil
+	}
+
+	e.approved = append(e.approved, restaurantID)
+
+	approvedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+
+	restaurant := *e.testRest
+	restaurant.IsActive = true
+	restaurant.ApprovedAt = &approvedAt
+	restaurant.ApprovedBy = &reviewer
+	return &restaurant, true, nil
+}
+
+func (e *mockEntityInterface) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	if e.testRest == nil || e.testRest.ID != restaurantID {
+		return nil, false, nil
+	}
+	if e.testRest.RejectedAt != nil || (e.testRest.IsActive && !overrule) {
+		return e.testRest, false, nil
+	}
+
+	e.rejected = append(e.rejected, reason)
+
+	rejectedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+	e.testRest.RejectedAt = &rejectedAt
+	e.testRest.RejectedBy = &reviewer
+	e.testRest.RejectionReason = &reason
+	return e.testRest, true, nil
+}
+
+type mockUnsubscribes struct {
+	services.UnsubscribeEntityInterface
+}
+
+func (m mockUnsubscribes) IsUnsubscribed(email string) (bool, error) {
+	return false, nil
+}
+
+type mockGeocoder struct{}
+
+func (g mockGeocoder) GeocodeAddress(street, city, state, zipcode, country string) (*models.GeoPoint, error) {
+	return &models.GeoPoint{Lat: 43.5, Lng: -96.7}, nil
+}
+
+func (g mockGeocoder) GeocodeZipcode(country, zipcode string) (*models.GeoPoint, error) {
+	return &models.GeoPoint{Lat: 43.5, Lng: -96.7}, nil
+}
+
+func testRestaurant() *models.Restaurant {
+	restaurant := &models.Restaurant{
+		Name:    "Bob's Burgers",
+		Type:    "Burgers",
+		Tags:    models.RestaurantTags{"burgers"},
+		Phone:   "(605) 555-0134",
+		Email:   "bob@gmail.com",
+		Address: "123 Ocean Ave",
+		City:    "Sioux Falls",
+		State:   "SD",
+		Zipcode: "57104",
+		Country: "US",
+	}
+	restaurant.ID = 4
+	restaurant.Takeout = true
+
+	return restaurant
+}
+
+func buildController() Controller {
+	return NewController(mux.NewRouter(), nil, nil, nil, nil, nil, nil, nil, services.SubmissionNotifier{}, testVerifier)
+}
+
+func buildEditController(e *mockEntityInterface, slack services.SlackClient) Controller {
+	return NewController(mux.NewRouter(), e, nil, nil, nil, nil, mockGeocoder{}, slack, services.SubmissionNotifier{}, testVerifier)
+}
+
+// fixture is an interaction with no actions, which the webhook answers with
+// a 400 once the request is let through.
+func fixture() string {
+	return payloadBody(`{"type": "block_actions", "team": {"id": ""}, "channel": {"id": ""}, "actions": []}`)
+}
+
+func payloadBody(payload string) string {
+	return url.Values{"payload": {payload}}.Encode()
+}
+
+func signedRequest(body string, timestamp time.Time, secret []byte) *http.Request {
+	return signedRequestTo("/slackadmin/webhook/", body, timestamp, secret)
+}
+
+func signedRequestTo(target string, body string, timestamp time.Time, secret []byte) *http.Request {
+	req, _ := http.NewRequest("POST", target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	signer := services.SlackVerifier{SigningSecret: secret}
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", signer.Sign(ts, []byte(body)))
+
+	return req
+}
+
+func serve(c Controller, req *http.Request) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	c.r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestSignedRequestIsAccepted(t *testing.T) {
+	c := buildController()
+
+	rr := serve(c, signedRequest(fixture(), time.Now(), testVerifier.SigningSecret))
+
+	// the signature checks out and the body is still readable by the webhook
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestKnownSignature(t *testing.T) {
+	// the example from Slack's documentation on verifying requests
+	verifier := services.SlackVerifier{SigningSecret: []byte("8f742231b10e8888abcd99yyyzzz85a5")}
+	body := "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+
+	assert.Equal(t, "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503", verifier.Sign("1531420618", []byte(body)))
+}
+
+func TestUnsignedRequestsAreRejected(t *testing.T) {
+	assert := assert.New(t)
+
+	c := buildController()
+	body := fixture()
+
+	tampered := signedRequest(body, time.Now(), testVerifier.SigningSecret)
+	tampered.Body = http.NoBody
+
+	unsigned, _ := http.NewRequest("POST", "/slackadmin/webhook/", strings.NewReader(body))
+
+	tests := []struct {
+		description string
+		req         *http.Request
+	}{
+		{"wrong secret", signedRequest(body, time.Now(), []byte("not-the-secret"))},
+		{"replayed", signedRequest(body, time.Now().Add(-10*time.Minute), testVerifier.SigningSecret)},
+		{"from the future", signedRequest(body, time.Now().Add(10*time.Minute), testVerifier.SigningSecret)},
+		{"tampered body", tampered},
+		{"no signature", unsigned},
+	}
+
+	for _, test := range tests {
+		rr := serve(c, test.req)
+		assert.Equal(http.StatusUnauthorized, rr.Code, test.description)
+	}
+}
+
+func TestMissingSecretRejectsEverything(t *testing.T) {
+	c := NewController(mux.NewRouter(), nil, nil, nil, nil, nil, nil, nil, services.SubmissionNotifier{}, services.SlackVerifier{})
+
+	rr := serve(c, signedRequest(fixture(), time.Now(), nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestEditButtonOpensModal(t *testing.T) {
+	assert := assert.New(t)
+
+	slack := &services.MemorySlackClient{}
+	c := buildEditController(&mockEntityInterface{testRest: testRestaurant()}, slack)
+
+	body := payloadBody(`{"type": "block_actions", "trigger_id": "123.456", "team": {"id": ""}, "channel": {"id": ""}, "user": {"name": "linda"}, "actions": [{"action_id": "edit_restaurant", "value": "4"}]}`)
+	rr := serve(c, signedRequest(body, time.Now(), testVerifier.SigningSecret))
+	assert.Equal(http.StatusOK, rr.Code)
+
+	views := slack.Views()
+	if !assert.Len(views, 1) {
+		return
+	}
+
+	assert.Equal("4", views[0].PrivateMetadata)
+
+	values := map[string]string{}
+	for _, block := range views[0].Blocks {
+		values[block.BlockID] = block.Element.InitialValue
+	}
+	assert.Equal("Bob's Burgers", values["name"])
+	assert.Equal("burgers", values["tags"])
+	assert.Equal("bob@gmail.com", values["email"])
+}
+
+// submission builds a view_submission payload with the listing's current
+// values, overridden by changes.
+func submission(changes map[string]string) string {
+	values := map[string]map[string]models.SlackViewValue{}
+	for _, block := range buildEditModal(*testRestaurant()).Blocks {
+		value := block.Element.InitialValue
+		if changed, ok := changes[block.BlockID]; ok {
+			value = changed
+		}
+		values[block.BlockID] = map[string]models.SlackViewValue{
+			modalValueAction: {Type: "plain_text_input", Value: value},
+		}
+	}
+
+	payload, _ := json.Marshal(models.SlackWebhookPost{
+		Type: "view_submission",
+		User: models.SlackUser{Name: "linda"},
+		View: &models.SlackView{
+			Type:            "modal",
+			CallbackID:      editModalCallbackID,
+			PrivateMetadata: "4",
+			State:           &models.SlackViewState{Values: values},
+		},
+	})
+
+	return payloadBody(string(payload))
+}
+
+func TestEditSubmissionSavesChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildEditController(e, &services.MemorySlackClient{})
+
+	body := submission(map[string]string{"name": "Bob's Burgers & Fries", "tags": "burgers, fries"})
+	rr := serve(c, signedRequest(body, time.Now(), testVerifier.SigningSecret))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Empty(rr.Body.String())
+
+	if assert.Len(e.updates, 1) {
+		update := e.updates[0]
+		assert.Equal("Bob's Burgers & Fries", *update.Name)
+		assert.Equal(models.RestaurantTags{"burgers", "fries"}, *update.Tags)
+
+		// untouched fields are left out, so the address isn't geocoded again
+		assert.Nil(update.Email)
+		assert.Nil(update.LatLng)
+		assert.Equal([]string{"linda"}, e.actors)
+	}
+}
+
+func TestEditSubmissionShowsErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildEditController(e, &services.MemorySlackClient{})
+
+	body := submission(map[string]string{"email": "not an email", "name": "", "url": "nope"})
+	rr := serve(c, signedRequest(body, time.Now(), testVerifier.SigningSecret))
+	assert.Equal(http.StatusOK, rr.Code)
+
+	var response models.SlackViewErrors
+	assert.NoError(json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal("errors", response.ResponseAction)
+	assert.Contains(response.Errors, "email")
+	assert.Contains(response.Errors, "name")
+	assert.Contains(response.Errors, "url")
+	assert.Len(response.Errors, 3)
+
+	body = submission(map[string]string{"phone": "call us"})
+	rr = serve(c, signedRequest(body, time.Now(), testVerifier.SigningSecret))
+	assert.Contains(rr.Body.String(), `"phone"`)
+
+	assert.Empty(e.updates)
+}
+
+func TestEditSubmissionIgnoresFieldsOutsideModal(t *testing.T) {
+	assert := assert.New(t)
+
+	// a stored value the modal can't show doesn't block edits to the rest
+	restaurant := testRestaurant()
+	restaurant.PaymentMethods = models.PaymentMethods{"bitcoin"}
+
+	e := &mockEntityInterface{testRest: restaurant}
+	c := buildEditController(e, &services.MemorySlackClient{})
+
+	body := submission(map[string]string{"name": "Bob's Burgers & Fries"})
+	rr := serve(c, signedRequest(body, time.Now(), testVerifier.SigningSecret))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Empty(rr.Body.String())
+	assert.Len(e.updates, 1)
+}
+
+func TestEditSubmissionGeocodesNewAddress(t *testing.T) {
+	assert := assert.New(t)
+
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildEditController(e, &services.MemorySlackClient{})
+
+	body := submission(map[string]string{"address": "200 Ocean Ave"})
+	rr := serve(c, signedRequest(body, time.Now(), testVerifier.SigningSecret))
+	assert.Equal(http.StatusOK, rr.Code)
+
+	if assert.Len(e.updates, 1) {
+		assert.Equal(&models.GeoPoint{Lat: 43.5, Lng: -96.7}, e.updates[0].LatLng)
+	}
+}
+
+type mockPhotoEntity struct {
+	services.PhotoEntityInterface
+	approvedBy []string
+}
+
+func (e *mockPhotoEntity) ApprovePhoto(photoID uint, reviewer string) (*models.Photo, bool, error) {
+	approved := len(e.approvedBy) == 0
+	if approved {
+		e.approvedBy = append(e.approvedBy, reviewer)
+	}
+
+	approvedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+	photo := &models.Photo{BusinessID: 4, IsApproved: true, ApprovedAt: &approvedAt, ApprovedBy: &e.approvedBy[0]}
+	photo.ID = photoID
+	return photo, approved, nil
+}
+
+type mockStatusEntity struct {
+	services.StatusUpdateEntityInterface
+	approvedBy []string
+}
+
+func (e *mockStatusEntity) ApproveStatusUpdate(statusID uint, reviewer string) (*models.StatusUpdate, bool, error) {
+	approved := len(e.approvedBy) == 0
+	if approved {
+		e.approvedBy = append(e.approvedBy, reviewer)
+	}
+
+	approvedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+	status := &models.StatusUpdate{BusinessID: 4, IsApproved: true, ApprovedAt: &approvedAt, ApprovedBy: &e.approvedBy[0]}
+	status.ID = statusID
+	return status, approved, nil
+}
+
+// responder stands in for an interaction's response_url and keeps the last
+// message posted to it.
+func responder() (*httptest.Server, *models.SlackResponseMsg) {
+	posted := new(models.SlackResponseMsg)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(posted)
+	}))
+
+	return server, posted
+}
+
+func click(responseURL string, user string, actionID string, value string) *http.Request {
+	payload, _ := json.Marshal(models.SlackWebhookPost{
+		Type:        "block_actions",
+		ResponseURL: responseURL,
+		User:        models.SlackUser{Name: user},
+		Actions:     []models.SlackPostedAction{{ActionID: actionID, Value: value}},
+	})
+
+	return signedRequest(payloadBody(string(payload)), time.Now(), testVerifier.SigningSecret)
+}
+
+func TestPhotoApprovalKeepsFirstReviewer(t *testing.T) {
+	assert := assert.New(t)
+
+	server, posted := responder()
+	defer server.Close()
+
+	pe := &mockPhotoEntity{}
+	c := NewController(mux.NewRouter(), nil, pe, nil, nil, nil, nil, nil, services.SubmissionNotifier{}, testVerifier)
+
+	rr := serve(c, click(server.URL, "linda", models.SlackActionApprovePhoto, "7"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(posted.Text, "Photo #7 for restaurant #4 has been approved by linda on <!date^1585841400^")
+
+	rr = serve(c, click(server.URL, "gene", models.SlackActionApprovePhoto, "7"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(posted.Text, "Photo #7 for restaurant #4 was already approved by linda on <!date^1585841400^")
+	assert.Equal([]string{"linda"}, pe.approvedBy)
+}
+
+func TestStatusApprovalKeepsFirstReviewer(t *testing.T) {
+	assert := assert.New(t)
+
+	server, posted := responder()
+	defer server.Close()
+
+	se := &mockStatusEntity{}
+	c := NewController(mux.NewRouter(), nil, nil, se, nil, nil, nil, nil, services.SubmissionNotifier{}, testVerifier)
+
+	rr := serve(c, click(server.URL, "linda", models.SlackActionApproveStatus, "3"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(posted.Text, "Status update #3 for restaurant #4 has been approved by linda")
+
+	rr = serve(c, click(server.URL, "gene", models.SlackActionApproveStatus, "3"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Contains(posted.Text, "Status update #3 for restaurant #4 was already approved by linda")
+	assert.Equal([]string{"linda"}, se.approvedBy)
+}
+
+func TestApproveReplacesOriginalMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	server, posted := responder()
+	defer server.Close()
+
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildEditController(e, nil)
+
+	rr := serve(c, click(server.URL, "linda", models.SlackActionApproveRestaurant, "4"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal([]uint{4}, e.approved)
+
+	assert.True(posted.ReplaceOriginal)
+	assert.Len(posted.Blocks, 1)
+	assert.Equal("section", posted.Blocks[0].Type)
+	assert.Contains(posted.Text, "*Bob's Burgers* has been approved by linda on <!date^1585841400^")
+}
+
+func TestApproveTwiceReportsFirstDecision(t *testing.T) {
+	assert := assert.New(t)
+
+	server, posted := responder()
+	defer server.Close()
+
+	approvedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+	approvedBy := "gene"
+
+	approved := testRestaurant()
+	approved.IsActive = true
+	approved.ApprovedAt = &approvedAt
+	approved.ApprovedBy = &approvedBy
+
+	e := &mockEntityInterface{testRest: approved}
+	c := buildEditController(e, nil)
+
+	rr := serve(c, click(server.URL, "linda", models.SlackActionApproveRestaurant, "4"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Empty(e.approved)
+
+	assert.True(posted.ReplaceOriginal)
+	assert.Contains(posted.Text, "*Bob's Burgers* was already approved by gene on <!date^1585841400^")
+	assert.Contains(posted.Text, "|Apr 2, 2020 15:30 UTC>")
+}
+
+func rejectChoice(responseURL string, value string) *http.Request {
+	payload, _ := json.Marshal(models.SlackWebhookPost{
+		Type:        "block_actions",
+		ResponseURL: responseURL,
+		User:        models.SlackUser{Name: "linda"},
+		Actions: []models.SlackPostedAction{{
+			ActionID:       models.SlackActionRejectRestaurant,
+			SelectedOption: &models.SlackMsgOption{Value: value},
+		}},
+	})
+
+	return signedRequest(payloadBody(string(payload)), time.Now(), testVerifier.SigningSecret)
+}
+
+func buildRejectController(e *mockEntityInterface, mailer services.Mailer) Controller {
+	notifier := services.SubmissionNotifier{
+		Mailer:       mailer,
+		Unsubscribes: mockUnsubscribes{},
+		Signer:       services.TokenSigner{Secret: []byte("test-secret")},
+	}
+
+	return NewController(mux.NewRouter(), e, nil, nil, nil, nil, mockGeocoder{}, nil, notifier, testVerifier)
+}
+
+func TestRejectRecordsReasonAndEmails(t *testing.T) {
+	assert := assert.New(t)
+
+	server, posted := responder()
+	defer server.Close()
+
+	mailer := &services.MemoryMailer{}
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildRejectController(e, mailer)
+
+	rr := serve(c, rejectChoice(server.URL, "4:duplicate"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal([]string{models.RejectionDuplicate}, e.rejected)
+	assert.True(posted.ReplaceOriginal)
+	assert.Contains(posted.Text, "*Bob's Burgers* has been rejected by linda: "+models.RejectionLabel(models.RejectionDuplicate))
+
+	if assert.Len(mailer.Messages(), 1) {
+		assert.Contains(mailer.Messages()[0].Text, "Reason: "+models.RejectionLabel(models.RejectionDuplicate))
+	}
+
+	// choosing again doesn't reject or email twice
+	rr = serve(c, rejectChoice(server.URL, "4:incomplete"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Len(e.rejected, 1)
+	assert.Len(mailer.Messages(), 1)
+	assert.Contains(posted.Text, "*Bob's Burgers* was already rejected by linda: "+models.RejectionLabel(models.RejectionDuplicate))
+}
+
+func TestRejectAfterApprovalKeepsListing(t *testing.T) {
+	assert := assert.New(t)
+
+	server, posted := responder()
+	defer server.Close()
+
+	approvedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+	approvedBy := "gene"
+
+	approved := testRestaurant()
+	approved.IsActive = true
+	approved.ApprovedAt = &approvedAt
+	approved.ApprovedBy = &approvedBy
+
+	mailer := &services.MemoryMailer{}
+	e := &mockEntityInterface{testRest: approved}
+	c := buildRejectController(e, mailer)
+
+	rr := serve(c, rejectChoice(server.URL, "4:duplicate"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Empty(e.rejected)
+	assert.Empty(mailer.Messages())
+	assert.True(posted.ReplaceOriginal)
+	assert.Contains(posted.Text, "*Bob's Burgers* was already approved by gene on <!date^1585841400^")
+}
+
+func TestApproveAfterRejectionKeepsRejection(t *testing.T) {
+	assert := assert.New(t)
+
+	server, posted := responder()
+	defer server.Close()
+
+	rejectedAt := time.Date(2020, 4, 2, 15, 30, 0, 0, time.UTC)
+	rejectedBy := "gene"
+	reason := models.RejectionDuplicate
+
+	rejected := testRestaurant()
+	rejected.RejectedAt = &rejectedAt
+	rejected.RejectedBy = &rejectedBy
+	rejected.RejectionReason = &reason
+
+	mailer := &services.MemoryMailer{}
+	e := &mockEntityInterface{testRest: rejected}
+	c := buildRejectController(e, mailer)
+
+	rr := serve(c, click(server.URL, "linda", models.SlackActionApproveRestaurant, "4"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Empty(e.approved)
+	assert.Empty(mailer.Messages())
+	assert.Contains(posted.Text, "*Bob's Burgers* was already rejected by gene: "+models.RejectionLabel(models.RejectionDuplicate)+" on <!date^1585841400^")
+}
+
+func TestRejectSpamSendsNoEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	server, _ := responder()
+	defer server.Close()
+
+	mailer := &services.MemoryMailer{}
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildRejectController(e, mailer)
+
+	rr := serve(c, rejectChoice(server.URL, "4:spam"))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal([]string{models.RejectionSpam}, e.rejected)
+	assert.Empty(mailer.Messages())
+}
+
+func TestRejectNeedsKnownReason(t *testing.T) {
+	assert := assert.New(t)
+
+	server, _ := responder()
+	defer server.Close()
+
+	e := &mockEntityInterface{testRest: testRestaurant()}
+	c := buildRejectController(e, &services.MemoryMailer{})
+
+	for _, value := range []string{"4:rude", "4", "spam", "4:"} {
+		rr := serve(c, rejectChoice(server.URL, value))
+		assert.Equal(http.StatusBadRequest, rr.Code, value)
+	}
+
+	rr := serve(c, rejectChoice(server.URL, "9:spam"))
+	assert.Equal(http.StatusNotFound, rr.Code)
+
+	assert.Empty(e.rejected)
+}
@@ -0,0 +1,132 @@
+package statusupdates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+const MaxStatusDuration = 90 * 24 * time.Hour
+
+type Controller struct {
+	r     *mux.Router
+	e     services.RestaurantEntityInterface
+	se    services.StatusUpdateEntityInterface
+	owner services.OwnerAuth
+}
+
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, sei services.StatusUpdateEntityInterface, owner services.OwnerAuth) Controller {
+	c := Controller{
+		r:     router,
+		e:     ei,
+		se:    sei,
+		owner: owner,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/restaurants").Subrouter()
+	s.HandleFunc("/{id}/status", c.create).Methods("POST")
+}
+
+func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	restID, err := strconv.ParseUint(strings.TrimSpace(vars["id"]), 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	newStatus := new(models.StatusUpdate)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&newStatus); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Data validation
+	validate := models.NewValidator()
+	if err := validate.Struct(newStatus); err != nil {
+		if _, ok := err.(*validator.InvalidValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var invalidFieldsStr strings.Builder
+
+		for _, err := range err.(validator.ValidationErrors) {
+			invalidFieldsStr.WriteString(err.Field() + ", ")
+		}
+
+		http.Error(w, fmt.Sprintf("Could not create status update as it contained missing or invalid fields: %s", invalidFieldsStr.String()), http.StatusBadRequest)
+		return
+	}
+
+	if err := newStatus.Translations.Validate(models.TranslatableStatusFields...); err != nil {
+		http.Error(w, fmt.Sprintf("Could not create status update as it contained invalid translations: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if newStatus.StartsAt.IsZero() {
+		newStatus.StartsAt = now
+	}
+
+	if !newStatus.EndsAt.After(newStatus.StartsAt) || !newStatus.EndsAt.After(now) {
+		http.Error(w, "Could not create status update as it must end in the future and after it starts", http.StatusBadRequest)
+		return
+	}
+
+	if newStatus.EndsAt.Sub(newStatus.StartsAt) > MaxStatusDuration {
+		http.Error(w, "Could not create status update as it cannot last longer than 90 days", http.StatusBadRequest)
+		return
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your status update: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil || !restaurant.IsPublic() {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	newStatus.BusinessID = restaurant.ID
+
+	// updates from a signed-in owner of the listing go live straight away
+	if session := c.owner.Authenticate(r); session != nil && session.Owns(restaurant.ID) {
+		newStatus.IsApproved = true
+	}
+
+	statusID, err := c.se.CreateStatusUpdate(*newStatus, *restaurant)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your status update: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	statusIDStr := strconv.Itoa(int(*statusID))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(statusIDStr + "\n"))
+	return
+}
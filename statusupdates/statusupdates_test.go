@@ -0,0 +1,320 @@
+package statusupdates
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type handlerTests struct {
+	description        string
+	url                string
+	method             string
+	token              string
+	body               io.Reader
+	expectedStatusCode int
+	expectedBody       string
+	entityClient       services.RestaurantEntityInterface
+	statusClient       services.StatusUpdateEntityInterface
+}
+
+var testSigner = services.TokenSigner{Secret: []byte("test-secret")}
+
+func buildController(ei services.RestaurantEntityInterface, sei services.StatusUpdateEntityInterface) Controller {
+	controller := NewController(mux.NewRouter(), ei, sei, services.OwnerAuth{Signer: testSigner})
+	return controller
+}
+
+func runTestCases(t *testing.T, testcases []handlerTests) {
+	assert := assert.New(t)
+
+	for _, testcase := range testcases {
+		c := buildController(testcase.entityClient, testcase.statusClient)
+
+		req, err := http.NewRequest(testcase.method, testcase.url, testcase.body)
+		assert.NoError(err)
+		if testcase.token != "" {
+			req.Header.Set("Authorization", "Bearer "+testcase.token)
+		}
+
+		rr := httptest.NewRecorder()
+		c.r.ServeHTTP(rr, req)
+
+		assert.Equal(testcase.expectedStatusCode, rr.Code, testcase.description)
+		assert.Equal(testcase.expectedBody, rr.Body.String(), testcase.description)
+	}
+}
+
+type mockTestMode int
+
+const (
+	Success mockTestMode = iota
+	Fail
+	Error
+	NotFound
+)
+
+type mockEntityInterface struct {
+	mode     mockTestMode
+	testRest *models.Restaurant
+}
+
+func (e mockEntityInterface) CreateRestaurant(newRestaurant models.Restaurant) (*uint, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetRestaurants(lat *float64, lng *float64, filters models.RestaurantFilters) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetRestaurant(id uint) (*models.Restaurant, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, nil
+	case Fail:
+		return nil, errors.New("could not find restaurant by id")
+	case NotFound:
+		return nil, nil
+	}
+
+	return nil, nil
+}
+
+func (e mockEntityInterface) MergeRestaurants(targetID uint, sourceID uint, choices models.MergeChoices, actor string) (*models.Restaurant, map[uint]uint, error) {
+	return nil, nil, nil
+}
+
+func (e mockEntityInterface) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) SearchRestaurants(term string, limit int) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetModerationStats() (*models.ModerationStats, error) {
+	return nil, nil
+}
+
+type mockStatusEntity struct {
+	mode     mockTestMode
+	approved bool
+}
+
+// CreateStatusUpdate fails unless the status has a start, belongs to the
+// listing and was approved only when expected.
+func (e mockStatusEntity) CreateStatusUpdate(newStatus models.StatusUpdate, restaurant models.Restaurant) (*uint, error) {
+	switch e.mode {
+	case Success:
+		if newStatus.StartsAt.IsZero() || newStatus.BusinessID != restaurant.ID {
+			return nil, errors.New("unexpected status update")
+		}
+		if newStatus.IsApproved != e.approved {
+			return nil, fmt.Errorf("expected approved to be %v", e.approved)
+		}
+
+		statusID := uint(1)
+		return &statusID, nil
+	case Fail:
+		return nil, errors.New("could not write status update to db")
+	}
+
+	return nil, nil
+}
+
+func (e mockStatusEntity) ApproveStatusUpdate(statusID uint, reviewer string) (*models.StatusUpdate, bool, error) {
+	return nil, false, nil
+}
+
+func testRestaurant() *models.Restaurant {
+	restaurant := &models.Restaurant{Name: "Bob's Burgers", IsActive: true}
+	restaurant.ID = 4
+
+	return restaurant
+}
+
+func statusBody(startsAt time.Time, endsAt time.Time) io.Reader {
+	return strings.NewReader(fmt.Sprintf(`{"type": "temporarily_closed", "message": "Closed for a private event", "starts_at": %q, "ends_at": %q}`,
+		startsAt.Format(time.RFC3339), endsAt.Format(time.RFC3339)))
+}
+
+func sessionFor(restaurantIDs ...uint) string {
+	token, _ := testSigner.Sign(services.TokenClaims{
+		Purpose:       services.TokenPurposeSession,
+		RestaurantIDs: restaurantIDs,
+		Email:         "bob@bobsburgers.example.com",
+		ExpiresAt:     time.Now().Add(time.Hour).Unix(),
+	})
+
+	return token
+}
+
+func TestCreateHandler(t *testing.T) {
+	now := time.Now()
+
+	rejectedAt := now
+	rejected := testRestaurant()
+	rejected.RejectedAt = &rejectedAt
+
+	tests := []handlerTests{
+		{
+			description:        "successful status update",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               statusBody(now, now.Add(24*time.Hour)),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       "1\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "starts now by default",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               strings.NewReader(fmt.Sprintf(`{"type": "special", "message": "Half price pies", "ends_at": %q}`, now.Add(time.Hour).Format(time.RFC3339))),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       "1\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "already ended",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               statusBody(now.Add(-48*time.Hour), now.Add(-time.Hour)),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create status update as it must end in the future and after it starts\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "ends before it starts",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               statusBody(now.Add(48*time.Hour), now.Add(24*time.Hour)),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create status update as it must end in the future and after it starts\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "longer than 90 days",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               statusBody(now, now.Add(MaxStatusDuration+time.Hour)),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create status update as it cannot last longer than 90 days\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "unknown type",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               strings.NewReader(`{"type": "on_fire", "message": "help", "ends_at": "2099-01-01T00:00:00Z"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create status update as it contained missing or invalid fields: Type, \n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "unknown restaurant",
+			url:                "/restaurants/9/status",
+			method:             "POST",
+			body:               statusBody(now, now.Add(time.Hour)),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: NotFound},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "rejected restaurant",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               statusBody(now, now.Add(time.Hour)),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: rejected},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "failed restaurant lookup",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               statusBody(now, now.Add(time.Hour)),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your status update: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Fail},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "failed status update creation",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			body:               statusBody(now, now.Add(time.Hour)),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your status update: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Fail},
+		},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCreateHandlerOwnerApproval(t *testing.T) {
+	now := time.Now()
+
+	tests := []handlerTests{
+		{
+			description:        "signed-in owner",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			token:              sessionFor(4),
+			body:               statusBody(now, now.Add(time.Hour)),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       "1\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success, approved: true},
+		}, {
+			description:        "owner of another listing",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			token:              sessionFor(5),
+			body:               statusBody(now, now.Add(time.Hour)),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       "1\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		}, {
+			description:        "bad token",
+			url:                "/restaurants/4/status",
+			method:             "POST",
+			token:              "not-a-token",
+			body:               statusBody(now, now.Add(time.Hour)),
+			expectedStatusCode: http.StatusCreated,
+			expectedBody:       "1\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			statusClient:       mockStatusEntity{mode: Success},
+		},
+	}
+
+	runTestCases(t, tests)
+}
@@ -0,0 +1,188 @@
+package suggestions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+// Each address can suggest a handful of edits an hour.
+const (
+	SuggestionLimit       = 5
+	SuggestionLimitWindow = time.Hour
+)
+
+type suggestionResponse struct {
+	PendingEditID uint `json:"pending_edit_id"`
+}
+
+type Controller struct {
+	r        *mux.Router
+	e        services.RestaurantEntityInterface
+	ee       services.EditEntityInterface
+	geocoder services.GeocodioServiceInterface
+	limiter  *services.RateLimiter
+	hasher   services.ClientHasher
+}
+
+func NewController(router *mux.Router, ei services.RestaurantEntityInterface, eei services.EditEntityInterface, geocoder services.GeocodioServiceInterface, limiter *services.RateLimiter, hasher services.ClientHasher) Controller {
+	c := Controller{
+		r:        router,
+		e:        ei,
+		ee:       eei,
+		geocoder: geocoder,
+		limiter:  limiter,
+		hasher:   hasher,
+	}
+
+	c.routes()
+
+	return c
+}
+
+func (c *Controller) routes() {
+	s := c.r.PathPrefix("/restaurants").Subrouter()
+	s.HandleFunc("/{id}/suggestions", c.create).Methods("POST")
+}
+
+// create stores a proposed change from the public for the moderators to
+// review. Nothing is applied until it is accepted.
+func (c *Controller) create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	restID, err := strconv.ParseUint(strings.TrimSpace(vars["id"]), 10, 64)
+	if err != nil {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	update := new(models.RestaurantUpdate)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the location always comes from geocoding the address
+	update.LatLng = nil
+
+	if update.IsEmpty() {
+		http.Error(w, "Could not create suggestion as it did not contain any changes", http.StatusBadRequest)
+		return
+	}
+
+	clientIP := services.ClientIP(r)
+	if !c.limiter.Allow(clientIP) {
+		http.Error(w, "Too many suggestions, please try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	restaurant, err := c.e.GetRestaurant(uint(restID))
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your suggestion: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	if restaurant == nil || !restaurant.IsPublic() {
+		http.Error(w, "restaurant not found", http.StatusNotFound)
+		return
+	}
+
+	// validate the listing as it would look with the suggestion applied
+	proposed := *restaurant
+	update.Apply(&proposed)
+
+	// don't bother the moderators with suggestions that match the listing
+	if len(models.DiffRestaurants(*restaurant, proposed)) == 0 && update.Translations == nil {
+		http.Error(w, "Could not create suggestion as it did not contain any changes", http.StatusBadRequest)
+		return
+	}
+
+	proposed.NormalizeAddress()
+
+	validate := models.NewValidator()
+	if err := validate.Struct(proposed); err != nil {
+		if _, ok := err.(*validator.InvalidValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var invalidFieldsStr strings.Builder
+
+		for _, err := range err.(validator.ValidationErrors) {
+			invalidFieldsStr.WriteString(err.Field() + ", ")
+		}
+
+		http.Error(w, fmt.Sprintf("Could not create suggestion as it contained missing or invalid fields: %s", invalidFieldsStr.String()), http.StatusBadRequest)
+		return
+	}
+
+	if err := proposed.NormalizePhone(); err != nil {
+		http.Error(w, "Could not create suggestion as it contained missing or invalid fields: Phone, ", http.StatusBadRequest)
+		return
+	}
+
+	if !proposed.ServiceModes.Any() {
+		http.Error(w, "Could not create suggestion as it must offer at least one service mode: takeout, curbside, delivery, drive_through", http.StatusBadRequest)
+		return
+	}
+
+	if update.Translations != nil {
+		if err := update.Translations.Validate(models.TranslatableRestaurantFields...); err != nil {
+			http.Error(w, fmt.Sprintf("Could not create suggestion as it contained invalid translations: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if update.ChangesAddress() {
+		point, err := c.geocoder.GeocodeAddress(
+			fmt.Sprintf("%s %s", proposed.Address, proposed.Address2),
+			proposed.City,
+			proposed.State,
+			proposed.Zipcode,
+			proposed.Country,
+		)
+		if err != nil {
+			eventID := sentry.CaptureException(err)
+			http.Error(w, fmt.Sprintf("There was a problem saving your suggestion: ID: %v", eventID), http.StatusInternalServerError)
+			return
+		}
+
+		if point == nil {
+			point = &models.GeoPoint{}
+		}
+
+		update.LatLng = point
+	}
+
+	// suggestions are anonymous, but moderators can still tell whether a
+	// run of them came from the same place
+	editID, err := c.ee.CreateEdit(models.BusinessEdit{
+		BusinessID: restaurant.ID,
+		Source:     models.EditSourceSuggestion,
+		Actor:      "visitor " + c.hasher.Hash(clientIP)[:8],
+		Changes:    *update,
+	}, *restaurant)
+	if err != nil {
+		eventID := sentry.CaptureException(err)
+		http.Error(w, fmt.Sprintf("There was a problem saving your suggestion: ID: %v", eventID), http.StatusInternalServerError)
+		return
+	}
+
+	payload, _ := json.Marshal(suggestionResponse{PendingEditID: *editID})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(payload)
+	return
+}
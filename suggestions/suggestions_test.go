@@ -0,0 +1,370 @@
+package suggestions
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/theproducer/openfortakeout_api/models"
+	"github.com/theproducer/openfortakeout_api/services"
+)
+
+type handlerTests struct {
+	description        string
+	url                string
+	method             string
+	body               io.Reader
+	expectedStatusCode int
+	expectedBody       string
+	entityClient       services.RestaurantEntityInterface
+	editClient         services.EditEntityInterface
+	geocoder           services.GeocodioServiceInterface
+	limiter            *services.RateLimiter
+}
+
+var testHasher = services.ClientHasher{Secret: []byte("test-secret")}
+
+const testClientIP = "203.0.113.9"
+
+func buildController(ei services.RestaurantEntityInterface, eei services.EditEntityInterface, geocoder services.GeocodioServiceInterface, limiter *services.RateLimiter) Controller {
+	if geocoder == nil {
+		geocoder = mockGeocoder{mode: Success}
+	}
+	if limiter == nil {
+		limiter = services.NewRateLimiter(SuggestionLimit, SuggestionLimitWindow)
+	}
+
+	controller := NewController(mux.NewRouter(), ei, eei, geocoder, limiter, testHasher)
+	return controller
+}
+
+func runTestCases(t *testing.T, testcases []handlerTests) {
+	assert := assert.New(t)
+
+	for _, testcase := range testcases {
+		c := buildController(testcase.entityClient, testcase.editClient, testcase.geocoder, testcase.limiter)
+
+		req, err := http.NewRequest(testcase.method, testcase.url, testcase.body)
+		assert.NoError(err)
+		req.RemoteAddr = testClientIP + ":5123"
+
+		rr := httptest.NewRecorder()
+		c.r.ServeHTTP(rr, req)
+
+		assert.Equal(testcase.expectedStatusCode, rr.Code, testcase.description)
+		assert.Equal(testcase.expectedBody, rr.Body.String(), testcase.description)
+	}
+}
+
+type mockTestMode int
+
+const (
+	Success mockTestMode = iota
+	Fail
+	Error
+	NotFound
+)
+
+type mockEntityInterface struct {
+	mode     mockTestMode
+	testRest *models.Restaurant
+}
+
+func (e mockEntityInterface) CreateRestaurant(newRestaurant models.Restaurant) (*uint, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) ApproveRestaurant(restaurantID uint, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) RejectRestaurant(restaurantID uint, reason string, reviewer string, overrule bool) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetRestaurants(lat *float64, lng *float64, filters models.RestaurantFilters) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetRestaurant(id uint) (*models.Restaurant, error) {
+	switch e.mode {
+	case Success:
+		return e.testRest, nil
+	case Fail:
+		return nil, errors.New("could not find restaurant by id")
+	case NotFound:
+		return nil, nil
+	}
+
+	return nil, nil
+}
+
+func (e mockEntityInterface) MergeRestaurants(targetID uint, sourceID uint, choices models.MergeChoices, actor string) (*models.Restaurant, map[uint]uint, error) {
+	return nil, nil, nil
+}
+
+func (e mockEntityInterface) UpdateRestaurant(id uint, update models.RestaurantUpdate, source string, actor string) (*models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) GetSubmissions(status string) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) SearchRestaurants(term string, limit int) (*[]models.Restaurant, error) {
+	return nil, nil
+}
+
+func (e mockEntityInterface) HideRestaurant(restaurantID uint, actor string) (*models.Restaurant, bool, error) {
+	return nil, false, nil
+}
+
+func (e mockEntityInterface) GetModerationStats() (*models.ModerationStats, error) {
+	return nil, nil
+}
+
+var geocodedPoint = models.GeoPoint{Lat: 43.5, Lng: -96.7}
+
+type mockEditEntity struct {
+	mode mockTestMode
+}
+
+// CreateEdit fails unless the controller filed the suggestion as an
+// anonymous visitor, with a location only when the address changed.
+func (e mockEditEntity) CreateEdit(newEdit models.BusinessEdit, restaurant models.Restaurant) (*uint, error) {
+	switch e.mode {
+	case Success:
+		if newEdit.BusinessID != restaurant.ID || newEdit.Source != models.EditSourceSuggestion || newEdit.Actor != "visitor "+testHasher.Hash(testClientIP)[:8] {
+			return nil, errors.New("unexpected edit")
+		}
+
+		latLng := newEdit.Changes.LatLng
+		if newEdit.Changes.ChangesAddress() != (latLng != nil) || (latLng != nil && *latLng != geocodedPoint) {
+			return nil, errors.New("unexpected edit location")
+		}
+
+		editID := uint(1)
+		return &editID, nil
+	case Fail:
+		return nil, errors.New("could not write edit to db")
+	}
+
+	return nil, nil
+}
+
+func (e mockEditEntity) ApproveEdit(editID uint, reviewer string) (*models.BusinessEdit, error) {
+	return nil, nil
+}
+
+func (e mockEditEntity) RejectEdit(editID uint, reviewer string) (*models.BusinessEdit, error) {
+	return nil, nil
+}
+
+type mockGeocoder struct {
+	mode mockTestMode
+}
+
+func (g mockGeocoder) GeocodeAddress(street, city, state, zipcode, country string) (*models.GeoPoint, error) {
+	switch g.mode {
+	case Success:
+		point := geocodedPoint
+		return &point, nil
+	case Fail:
+		return nil, errors.New("could not geocode address")
+	}
+
+	return nil, nil
+}
+
+func (g mockGeocoder) GeocodeZipcode(country, zipcode string) (*models.GeoPoint, error) {
+	return g.GeocodeAddress("", "", "", zipcode, country)
+}
+
+func testRestaurant() *models.Restaurant {
+	restaurant := &models.Restaurant{
+		Name:    "Bob's Burgers",
+		Type:    "Burgers",
+		Phone:   "(605) 555-0134",
+		Email:   "bob@gmail.com",
+		Address: "123 Ocean Ave",
+		City:    "Sioux Falls",
+		State:   "SD",
+		Zipcode: "57104",
+		Country: "US",
+	}
+	restaurant.ID = 4
+	restaurant.Takeout = true
+	restaurant.IsActive = true
+
+	return restaurant
+}
+
+const acceptedBody = `{"pending_edit_id":1}`
+
+func TestCreateHandler(t *testing.T) {
+	rejectedAt := time.Now()
+	rejected := testRestaurant()
+	rejected.RejectedAt = &rejectedAt
+
+	tests := []handlerTests{
+		{
+			description:        "successful suggestion",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"phone": "(605) 555-0199", "hours": "11am - 9pm"}`),
+			expectedStatusCode: http.StatusAccepted,
+			expectedBody:       acceptedBody,
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "address suggestion is geocoded, ignoring the sent location",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"address": "200 Ocean Ave", "latlng": {"lat": 1, "lng": 1}}`),
+			expectedStatusCode: http.StatusAccepted,
+			expectedBody:       acceptedBody,
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "empty suggestion",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create suggestion as it did not contain any changes\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "unknown field",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"hours": "11am - 9pm", "owner": true}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "json: unknown field \"owner\"\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "invalid email",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"email": "not an email"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create suggestion as it contained missing or invalid fields: Email, \n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "invalid phone",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"phone": "call us"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create suggestion as it contained missing or invalid fields: Phone, \n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "suggestion matching the listing",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"name": "Bob's Burgers"}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create suggestion as it did not contain any changes\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "no service modes left",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"service_modes": {"takeout": false}}`),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Could not create suggestion as it must offer at least one service mode: takeout, curbside, delivery, drive_through\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "unknown restaurant",
+			url:                "/restaurants/9/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"hours": "11am - 9pm"}`),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: NotFound},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "rejected restaurant",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"hours": "11am - 9pm"}`),
+			expectedStatusCode: http.StatusNotFound,
+			expectedBody:       "restaurant not found\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: rejected},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "failed restaurant lookup",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"hours": "11am - 9pm"}`),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your suggestion: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Fail},
+			editClient:         mockEditEntity{mode: Success},
+		}, {
+			description:        "failed geocoding",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"address": "200 Ocean Ave"}`),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your suggestion: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Success},
+			geocoder:           mockGeocoder{mode: Fail},
+		}, {
+			description:        "failed edit creation",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"hours": "11am - 9pm"}`),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedBody:       "There was a problem saving your suggestion: ID: <nil>\n",
+			entityClient:       mockEntityInterface{mode: Success, testRest: testRestaurant()},
+			editClient:         mockEditEntity{mode: Fail},
+		},
+	}
+
+	runTestCases(t, tests)
+}
+
+func TestCreateHandlerRateLimit(t *testing.T) {
+	entity := mockEntityInterface{mode: Success, testRest: testRestaurant()}
+	edits := mockEditEntity{mode: Success}
+	limiter := services.NewRateLimiter(1, time.Hour)
+
+	tests := []handlerTests{
+		{
+			description:        "suggestion within the limit",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"hours": "11am - 9pm"}`),
+			expectedStatusCode: http.StatusAccepted,
+			expectedBody:       acceptedBody,
+			entityClient:       entity,
+			editClient:         edits,
+			limiter:            limiter,
+		}, {
+			description:        "suggestion over the limit",
+			url:                "/restaurants/4/suggestions",
+			method:             "POST",
+			body:               strings.NewReader(`{"hours": "11am - 10pm"}`),
+			expectedStatusCode: http.StatusTooManyRequests,
+			expectedBody:       "Too many suggestions, please try again later\n",
+			entityClient:       entity,
+			editClient:         edits,
+			limiter:            limiter,
+		},
+	}
+
+	runTestCases(t, tests)
+}